@@ -1,6 +1,8 @@
 package wserest
 
 import (
+	"context"
+
 	"github.com/sebastien4/wse-rest-library-go/entity/application"
 	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
 	"github.com/sebastien4/wse-rest-library-go/entity/base"
@@ -9,6 +11,11 @@ import (
 // Application Operations
 type Application struct {
 	wowza
+	name        string
+	appType     string
+	readAccess  string
+	writeAccess string
+	description string
 }
 
 // WSEApps is struct for GetAll() applications
@@ -54,58 +61,71 @@ func NewApplication(
 
 	a := new(Application)
 	a.init(settings)
-	a.props["name"] = name
-	a.props["appType"] = appType
-	a.props["clientStreamReadAccess"] = readAccess
-	a.props["clientStreamWriteAccess"] = writeAccess
-	a.props["description"] = description
-	a.baseURI = a.host() + "/servers/" + a.serverInstance() + "/vhosts/" + a.vHostInstance() + "/applications/" + name
+	a.name = name
+	a.appType = appType
+	a.readAccess = readAccess
+	a.writeAccess = writeAccess
+	a.description = description
+	a.baseURI = a.vhostURI().join("applications", name).String()
 	return a
 }
 
-func (a *Application) setParameters() {
-	a.AddSkipParameter("name")
-	a.AddSkipParameter("clientStreamReadAccess")
-	a.AddSkipParameter("appType")
-	a.AddSkipParameter("clientStreamWriteAccess")
-	a.AddSkipParameter("description")
+// configProps returns the properties describing the Application
+func (a *Application) configProps() map[string]interface{} {
+	props := requestProps(a.baseURI)
+	props["name"] = a.name
+	props["appType"] = a.appType
+	props["clientStreamReadAccess"] = a.readAccess
+	props["clientStreamWriteAccess"] = a.writeAccess
+	props["description"] = a.description
+	return props
 }
 
 // Get retrieves the specified Application configuration
 func (a *Application) Get() (map[string]interface{}, error) {
-	a.setParameters()
-
-	a.setRestURI(a.baseURI)
+	return a.GetWithContext(context.Background())
+}
 
-	return a.sendRequest(a.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+// GetWithContext is like Get but honors ctx for cancellation and deadlines
+func (a *Application) GetWithContext(ctx context.Context) (map[string]interface{}, error) {
+	return a.sendRequest(ctx, requestProps(a.baseURI), []base.Entity{}, GET, "")
 }
 
 // GetAdvanced retrieves the specified advanced Application configuration
 func (a *Application) GetAdvanced() (map[string]interface{}, error) {
-	a.setParameters()
+	return a.GetAdvancedWithContext(context.Background())
+}
 
-	a.setRestURI(a.baseURI + "/adv")
+// GetAdvancedWithContext is like GetAdvanced but honors ctx for cancellation and deadlines
+func (a *Application) GetAdvancedWithContext(ctx context.Context) (map[string]interface{}, error) {
+	restURI := a.subURI("adv").String()
 
-	return a.sendRequest(a.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return a.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // GetAllOld retrieves the list of Applications
 func (a *Application) GetAllOld() (map[string]interface{}, error) {
-	a.setParameters()
+	return a.GetAllOldWithContext(context.Background())
+}
 
-	a.setRestURI(a.host() + "/servers/" + a.serverInstance() + "/vhosts/" + a.vHostInstance() + "/applications")
+// GetAllOldWithContext is like GetAllOld but honors ctx for cancellation and deadlines
+func (a *Application) GetAllOldWithContext(ctx context.Context) (map[string]interface{}, error) {
+	restURI := a.vhostURI().join("applications").String()
 
-	return a.sendRequest(a.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return a.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // GetAll retrieves the list of Applications
 func (a *Application) GetAll() (WSEApps, error) {
-	a.setParameters()
+	return a.GetAllWithContext(context.Background())
+}
 
-	a.setRestURI(a.host() + "/servers/" + a.serverInstance() + "/vhosts/" + a.vHostInstance() + "/applications")
+// GetAllWithContext is like GetAll but honors ctx for cancellation and deadlines
+func (a *Application) GetAllWithContext(ctx context.Context) (WSEApps, error) {
+	restURI := a.vhostURI().join("applications").String()
 
 	var r WSEApps
-	err := a.sendRequestSeb(&r, a.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	err := a.sendRequestSeb(ctx, &r, requestProps(restURI), []base.Entity{}, GET, "")
 	return r, err
 }
 
@@ -118,8 +138,19 @@ func (a *Application) Create(
 	transConfig *application.TranscoderConfig,
 	drmConfig *application.DrmConfig,
 ) (map[string]interface{}, error) {
-	a.setRestURI(a.baseURI)
+	return a.CreateWithContext(context.Background(), streamConfig, securityConfig, modules, dvrConfig, transConfig, drmConfig)
+}
 
+// CreateWithContext is like Create but honors ctx for cancellation and deadlines
+func (a *Application) CreateWithContext(
+	ctx context.Context,
+	streamConfig *application.StreamConfig,
+	securityConfig *application.SecurityConfig,
+	modules *application.Modules,
+	dvrConfig *application.DvrConfig,
+	transConfig *application.TranscoderConfig,
+	drmConfig *application.DrmConfig,
+) (map[string]interface{}, error) {
 	args := []base.Entity{}
 	if streamConfig != nil {
 		args = append(args, streamConfig)
@@ -141,7 +172,7 @@ func (a *Application) Create(
 	}
 	entities := a.getEntities(args, a.baseURI)
 
-	return a.sendRequest(a.preparePropertiesForRequest(), entities, POST, "")
+	return a.sendRequest(ctx, a.configProps(), entities, POST, "")
 }
 
 // Update updates the specified Application configuration
@@ -153,8 +184,19 @@ func (a *Application) Update(
 	transConfig *application.TranscoderConfig,
 	drmConfig *application.DrmConfig,
 ) (map[string]interface{}, error) {
-	a.setRestURI(a.baseURI)
+	return a.UpdateWithContext(context.Background(), streamConfig, securityConfig, modules, dvrConfig, transConfig, drmConfig)
+}
 
+// UpdateWithContext is like Update but honors ctx for cancellation and deadlines
+func (a *Application) UpdateWithContext(
+	ctx context.Context,
+	streamConfig *application.StreamConfig,
+	securityConfig *application.SecurityConfig,
+	modules *application.Modules,
+	dvrConfig *application.DvrConfig,
+	transConfig *application.TranscoderConfig,
+	drmConfig *application.DrmConfig,
+) (map[string]interface{}, error) {
 	args := []base.Entity{}
 	if streamConfig != nil {
 		args = append(args, streamConfig)
@@ -176,27 +218,35 @@ func (a *Application) Update(
 	}
 	entities := a.getEntities(args, a.baseURI)
 
-	return a.sendRequest(a.preparePropertiesForRequest(), entities, PUT, "")
+	return a.sendRequest(ctx, a.configProps(), entities, PUT, "")
 }
 
 // UpdateAdvanced updates the specified advanced Application configuration
 func (a *Application) UpdateAdvanced(advancedSettings *application.AdvancedSettings, modules *application.Modules) (map[string]interface{}, error) {
+	return a.UpdateAdvancedWithContext(context.Background(), advancedSettings, modules)
+}
+
+// UpdateAdvancedWithContext is like UpdateAdvanced but honors ctx for cancellation and deadlines
+func (a *Application) UpdateAdvancedWithContext(ctx context.Context, advancedSettings *application.AdvancedSettings, modules *application.Modules) (map[string]interface{}, error) {
 	entities := a.getEntities(nil, a.baseURI)
-	props := make(map[string]interface{})
+	props := requestProps(a.subURI("adv").String())
 	props["advancedSettings"] = advancedSettings.AdvancedSettings
 	props["modules"] = modules.ModuleList
-	props["restURI"] = a.baseURI + "/adv"
 
-	return a.sendRequest(props, entities, PUT, "")
+	return a.sendRequest(ctx, props, entities, PUT, "")
 }
 
 // Remove deletes the specified Application configuration
 func (a *Application) Remove() (map[string]interface{}, error) {
-	a.setRestURI(a.baseURI)
-	return a.sendRequest(a.preparePropertiesForRequest(), []base.Entity{}, DELETE, "")
+	return a.RemoveWithContext(context.Background())
+}
+
+// RemoveWithContext is like Remove but honors ctx for cancellation and deadlines
+func (a *Application) RemoveWithContext(ctx context.Context) (map[string]interface{}, error) {
+	return a.sendRequest(ctx, requestProps(a.baseURI), []base.Entity{}, DELETE, "")
 }
 
 // Name return name property
 func (a *Application) Name() string {
-	return a.props["name"].(string)
+	return a.name
 }
@@ -51,17 +51,15 @@ func TestApplication(t *testing.T) {
 	// Create this application
 	wowzaApplication := NewApplication(settings, "live", "", "", "", "")
 
-	if UseWowza == "" {
-		return
-	}
+	useCassette(t, settings, "application")
 
-	response, err := wowzaApplication.GetAll()
+	apps, err := wowzaApplication.GetAll()
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log(response)
+	t.Log(apps)
 
-	response, err = wowzaApplication.Get()
+	response, err := wowzaApplication.Get()
 	if err != nil {
 		t.Fatal(err)
 	}
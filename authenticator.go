@@ -0,0 +1,121 @@
+package wserest
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+	"github.com/sebastien4/wse-rest-library-go/redact"
+)
+
+// maxChallenges bounds how many 401 challenges are answered for a single
+// request
+const maxChallenges = 2
+
+var (
+	_ helper.Authenticator = NoAuthenticator{}
+	_ helper.Authenticator = (*BasicAuthenticator)(nil)
+	_ helper.Authenticator = (*DigestAuthenticator)(nil)
+	_ helper.Authenticator = (*BearerAuthenticator)(nil)
+	_ helper.Authenticator = (*HeaderAuthenticator)(nil)
+)
+
+// authorize has auth add its credentials to req, the headers it sets are
+// marked as secret in the context of the returned request so that they are
+// redacted wherever the request is logged or recorded
+func authorize(auth helper.Authenticator, req *http.Request, body []byte) (*http.Request, error) {
+	before := req.Header.Clone()
+	if err := auth.Authorize(req, body); err != nil {
+		return nil, err
+	}
+	var secrets []string
+	for name, values := range req.Header {
+		if !slices.Equal(before[name], values) {
+			secrets = append(secrets, name)
+		}
+	}
+	if len(secrets) == 0 {
+		return req, nil
+	}
+	return req.WithContext(redact.WithSecretHeaders(req.Context(), secrets...)), nil
+}
+
+// NoAuthenticator sends requests without credentials
+type NoAuthenticator struct{}
+
+// Authorize leaves req untouched
+func (NoAuthenticator) Authorize(req *http.Request, body []byte) error {
+	return nil
+}
+
+// Challenge never retries
+func (NoAuthenticator) Challenge(resp *http.Response) (bool, error) {
+	return false, nil
+}
+
+// BasicAuthenticator sends HTTP Basic credentials with every request
+type BasicAuthenticator struct {
+	username string
+	password string
+}
+
+// NewBasicAuthenticator creates a BasicAuthenticator
+func NewBasicAuthenticator(username, password string) *BasicAuthenticator {
+	return &BasicAuthenticator{username: username, password: password}
+}
+
+// Authorize sets the Basic Authorization header of req
+func (b *BasicAuthenticator) Authorize(req *http.Request, body []byte) error {
+	req.SetBasicAuth(b.username, b.password)
+	return nil
+}
+
+// Challenge never retries, the credentials were already sent
+func (b *BasicAuthenticator) Challenge(resp *http.Response) (bool, error) {
+	return false, nil
+}
+
+// BearerAuthenticator sends a bearer token with every request, typically
+// for a reverse proxy in front of the REST API
+type BearerAuthenticator struct {
+	token string
+}
+
+// NewBearerAuthenticator creates a BearerAuthenticator
+func NewBearerAuthenticator(token string) *BearerAuthenticator {
+	return &BearerAuthenticator{token: token}
+}
+
+// Authorize sets the Bearer Authorization header of req
+func (b *BearerAuthenticator) Authorize(req *http.Request, body []byte) error {
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	return nil
+}
+
+// Challenge never retries, the token was already sent
+func (b *BearerAuthenticator) Challenge(resp *http.Response) (bool, error) {
+	return false, nil
+}
+
+// HeaderAuthenticator sends a custom header with every request, such as an
+// API key expected by a gateway
+type HeaderAuthenticator struct {
+	name  string
+	value string
+}
+
+// NewHeaderAuthenticator creates a HeaderAuthenticator
+func NewHeaderAuthenticator(name, value string) *HeaderAuthenticator {
+	return &HeaderAuthenticator{name: name, value: value}
+}
+
+// Authorize sets the header of req
+func (h *HeaderAuthenticator) Authorize(req *http.Request, body []byte) error {
+	req.Header.Set(h.name, h.value)
+	return nil
+}
+
+// Challenge never retries, the header was already sent
+func (h *HeaderAuthenticator) Challenge(resp *http.Response) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,61 @@
+package wserest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestAuthenticators(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		auth   helper.Authenticator
+		header string
+		want   string
+	}{
+		{NoAuthenticator{}, "Authorization", ""},
+		{NewBasicAuthenticator("admin", "secret"), "Authorization", "Basic YWRtaW46c2VjcmV0"},
+		{NewBearerAuthenticator("token"), "Authorization", "Bearer token"},
+		{NewHeaderAuthenticator("X-Api-Key", "key"), "X-Api-Key", "key"},
+	}
+	for _, tt := range tests {
+		settings := helper.NewDefaultSettings()
+		settings.SetHost(ts.URL + "/v2")
+		settings.SetUseDigest(true)
+		settings.SetAuthenticator(tt.auth)
+
+		if _, err := NewServer(settings).GetUsers(); err != nil {
+			t.Fatal(err)
+		}
+		if v := got.Get(tt.header); v != tt.want {
+			t.Errorf("%T: got %s %q, want %q", tt.auth, tt.header, v, tt.want)
+		}
+	}
+}
+
+func TestDigestAuthenticatorOwnCache(t *testing.T) {
+	ds := &digestServer{algorithm: "MD5", qop: "auth"}
+	ts := httptest.NewServer(ds)
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetAuthenticator(NewDigestAuthenticator("admin", "secret"))
+
+	for i := 0; i < 2; i++ {
+		if _, err := NewServer(settings).GetUsers(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ds.challenges != 1 {
+		t.Fatalf("expected a single challenge, got %d", ds.challenges)
+	}
+}
@@ -3,15 +3,17 @@ package wserest
 import (
 	"bytes"
 	"crypto/md5"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
 	"hash"
 	"io"
 	"net/url"
-	"regexp"
 	"strings"
-	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/httpauth"
 )
 
 type authorization struct {
@@ -26,10 +28,32 @@ type authorization struct {
 	URI       string // quoted
 	Userhash  bool   // quoted
 	Username  string // quoted
+
+	// sessionA1 is computed once per nonce for the -sess algorithms,
+	// RFC 7616 section 3.4.2
+	sessionA1 string
+}
+
+// algorithmRank orders the supported digest algorithms from weakest to
+// strongest, 0 means unsupported
+func algorithmRank(algorithm string) int {
+	switch strings.ToUpper(algorithm) {
+	case "", "MD5", "MD5-SESS":
+		return 1
+	case "SHA-256", "SHA-256-SESS":
+		return 2
+	case "SHA-512-256", "SHA-512-256-SESS":
+		return 3
+	}
+	return 0
 }
 
 func newAuthorization(dr *digestRequest) (*authorization, error) {
 
+	if algorithmRank(dr.Wa.Algorithm) == 0 {
+		return nil, fmt.Errorf("unsupported digest algorithm %q", dr.Wa.Algorithm)
+	}
+
 	ah := authorization{
 		Algorithm: dr.Wa.Algorithm,
 		Cnonce:    "",
@@ -44,9 +68,21 @@ func newAuthorization(dr *digestRequest) (*authorization, error) {
 		Username:  "",
 	}
 
+	qops := dr.Wa.qops()
+	for _, qop := range qops {
+		if qop == "auth-int" || (qop == "auth" && ah.Qop == "") {
+			ah.Qop = qop
+		}
+	}
+	if len(qops) > 0 && ah.Qop == "" {
+		return nil, fmt.Errorf("unsupported digest qop %q", dr.Wa.Qop)
+	}
+
 	return ah.refreshAuthorization(dr)
 }
 
+// refreshAuthorization computes the authorization of a new request reusing
+// the same nonce, incrementing the nonce count
 func (ah *authorization) refreshAuthorization(dr *digestRequest) (*authorization, error) {
 
 	ah.Username = dr.Username
@@ -55,15 +91,25 @@ func (ah *authorization) refreshAuthorization(dr *digestRequest) (*authorization
 		ah.Username = ah.hash(fmt.Sprintf("%s:%s", ah.Username, ah.Realm))
 	}
 
-	ah.Nc++
-
-	ah.Cnonce = ah.hash(fmt.Sprintf("%d:%s:my_value", time.Now().UnixNano(), dr.Username))
-
 	url, err := url.Parse(dr.URI)
 	if err != nil {
 		return nil, err
 	}
 
+	if ah.Qop != "" {
+		ah.Nc++
+
+		// a session keeps the cnonce of its first request, A1 is computed
+		// from it
+		if ah.Cnonce == "" || !ah.session() {
+			b := make([]byte, 16)
+			if _, err = rand.Read(b); err != nil {
+				return nil, err
+			}
+			ah.Cnonce = hex.EncodeToString(b)
+		}
+	}
+
 	ah.URI = url.RequestURI()
 	ah.Response = ah.computeResponse(dr)
 
@@ -73,48 +119,53 @@ func (ah *authorization) refreshAuthorization(dr *digestRequest) (*authorization
 func (ah *authorization) computeResponse(dr *digestRequest) (s string) {
 
 	kdSecret := ah.hash(ah.computeA1(dr))
+	if ah.Qop == "" {
+		// RFC 2069 compatibility
+		return ah.hash(fmt.Sprintf("%s:%s:%s", kdSecret, ah.Nonce, ah.hash(ah.computeA2(dr))))
+	}
 	kdData := fmt.Sprintf("%s:%08x:%s:%s:%s", ah.Nonce, ah.Nc, ah.Cnonce, ah.Qop, ah.hash(ah.computeA2(dr)))
 
 	return ah.hash(fmt.Sprintf("%s:%s", kdSecret, kdData))
 }
 
-func (ah *authorization) computeA1(dr *digestRequest) string {
+// session reports whether the algorithm is a -sess one
+func (ah *authorization) session() bool {
+	return strings.HasSuffix(strings.ToLower(ah.Algorithm), "-sess")
+}
 
-	if ah.Algorithm == "" || ah.Algorithm == "MD5" || ah.Algorithm == "SHA-256" {
-		return fmt.Sprintf("%s:%s:%s", ah.Username, ah.Realm, dr.Password)
-	}
+func (ah *authorization) computeA1(dr *digestRequest) string {
 
-	if ah.Algorithm == "MD5-sess" || ah.Algorithm == "SHA-256-sess" {
-		upHash := ah.hash(fmt.Sprintf("%s:%s:%s", ah.Username, ah.Realm, dr.Password))
-		return fmt.Sprintf("%s:%s:%s", upHash, ah.Nonce, ah.Cnonce)
+	if ah.session() {
+		if ah.sessionA1 == "" {
+			upHash := ah.hash(fmt.Sprintf("%s:%s:%s", dr.Username, ah.Realm, dr.Password))
+			ah.sessionA1 = fmt.Sprintf("%s:%s:%s", upHash, ah.Nonce, ah.Cnonce)
+		}
+		return ah.sessionA1
 	}
 
-	return ""
+	return fmt.Sprintf("%s:%s:%s", dr.Username, ah.Realm, dr.Password)
 }
 
 func (ah *authorization) computeA2(dr *digestRequest) string {
 
-	if matched, _ := regexp.MatchString("auth-int", dr.Wa.Qop); matched {
-		ah.Qop = "auth-int"
+	if ah.Qop == "auth-int" {
 		return fmt.Sprintf("%s:%s:%s", dr.Method, ah.URI, ah.hash(dr.Body))
 	}
 
-	if dr.Wa.Qop == "auth" || dr.Wa.Qop == "" {
-		ah.Qop = "auth"
-		return fmt.Sprintf("%s:%s", dr.Method, ah.URI)
-	}
-
-	return ""
+	return fmt.Sprintf("%s:%s", dr.Method, ah.URI)
 }
 
 func (ah *authorization) hash(a string) (s string) {
 
 	var h hash.Hash
 
-	if ah.Algorithm == "" || ah.Algorithm == "MD5" || ah.Algorithm == "MD5-sess" {
-		h = md5.New()
-	} else if ah.Algorithm == "SHA-256" || ah.Algorithm == "SHA-256-sess" {
+	switch algorithmRank(ah.Algorithm) {
+	case 3:
+		h = sha512.New512_256()
+	case 2:
 		h = sha256.New()
+	default:
+		h = md5.New()
 	}
 
 	io.WriteString(h, a)
@@ -133,7 +184,7 @@ func (ah *authorization) toString() string {
 	}
 
 	if ah.Cnonce != "" {
-		buffer.WriteString(fmt.Sprintf("cnonce=\"%s\", ", ah.Cnonce))
+		buffer.WriteString(fmt.Sprintf("cnonce=%s, ", httpauth.QuoteString(ah.Cnonce)))
 	}
 
 	if ah.Nc != 0 {
@@ -141,11 +192,11 @@ func (ah *authorization) toString() string {
 	}
 
 	if ah.Opaque != "" {
-		buffer.WriteString(fmt.Sprintf("opaque=\"%s\", ", ah.Opaque))
+		buffer.WriteString(fmt.Sprintf("opaque=%s, ", httpauth.QuoteString(ah.Opaque)))
 	}
 
 	if ah.Nonce != "" {
-		buffer.WriteString(fmt.Sprintf("nonce=\"%s\", ", ah.Nonce))
+		buffer.WriteString(fmt.Sprintf("nonce=%s, ", httpauth.QuoteString(ah.Nonce)))
 	}
 
 	if ah.Qop != "" {
@@ -153,15 +204,15 @@ func (ah *authorization) toString() string {
 	}
 
 	if ah.Realm != "" {
-		buffer.WriteString(fmt.Sprintf("realm=\"%s\", ", ah.Realm))
+		buffer.WriteString(fmt.Sprintf("realm=%s, ", httpauth.QuoteString(ah.Realm)))
 	}
 
 	if ah.Response != "" {
-		buffer.WriteString(fmt.Sprintf("response=\"%s\", ", ah.Response))
+		buffer.WriteString(fmt.Sprintf("response=%s, ", httpauth.QuoteString(ah.Response)))
 	}
 
 	if ah.URI != "" {
-		buffer.WriteString(fmt.Sprintf("uri=\"%s\", ", ah.URI))
+		buffer.WriteString(fmt.Sprintf("uri=%s, ", httpauth.QuoteString(ah.URI)))
 	}
 
 	if ah.Userhash {
@@ -169,7 +220,7 @@ func (ah *authorization) toString() string {
 	}
 
 	if ah.Username != "" {
-		buffer.WriteString(fmt.Sprintf("username=\"%s\", ", ah.Username))
+		buffer.WriteString(fmt.Sprintf("username=%s, ", httpauth.QuoteString(ah.Username)))
 	}
 
 	s := buffer.String()
@@ -0,0 +1,175 @@
+package wserest
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// responseCaches holds the cache of each cache configuration
+var responseCaches sync.Map
+
+type cacheEntry struct {
+	resp    *helper.Response
+	expires time.Time
+}
+
+// flight is a GET being sent on behalf of every caller waiting for it
+type flight struct {
+	done chan struct{}
+	resp *helper.Response
+	err  error
+}
+
+// responseCache keeps the successful GET responses and collapses concurrent
+// identical GETs into a single request
+type responseCache struct {
+	mu      sync.Mutex
+	config  *helper.Cache
+	entries map[string]cacheEntry
+	flights map[string]*flight
+	// generation changes with every invalidation so that responses read
+	// before one are not cached
+	generation uint64
+}
+
+// cache returns the response cache of the settings, nil when disabled
+func (w *wowza) cache() *responseCache {
+	config := w.settings.Cache()
+	if config == nil {
+		return nil
+	}
+	if c, ok := responseCaches.Load(config); ok {
+		return c.(*responseCache)
+	}
+	c, _ := responseCaches.LoadOrStore(config, &responseCache{
+		config:  config,
+		entries: make(map[string]cacheEntry),
+		flights: make(map[string]*flight),
+	})
+	return c.(*responseCache)
+}
+
+// PurgeCache drops every response cached for settings
+func PurgeCache(settings *helper.Settings) {
+	if config := settings.Cache(); config != nil {
+		if c, ok := responseCaches.Load(config); ok {
+			c.(*responseCache).invalidate("")
+		}
+	}
+}
+
+// sendCached sends req through the cache: GETs are answered from it when
+// possible and other requests invalidate it. A dry run bypasses the cache,
+// every request is captured and its fake answer is not kept.
+func (w *wowza) sendCached(ctx context.Context, req *helper.Request) (*helper.Response, error) {
+	c := w.cache()
+	if c == nil || w.settings.DryRun() != nil {
+		return w.send(ctx, req)
+	}
+	if req.Method != GET.String() {
+		defer c.invalidate(req.URI)
+		return w.send(ctx, req)
+	}
+	ttl := c.config.TTLFor(resourceOf(req.URI))
+	if ttl <= 0 {
+		return w.send(ctx, req)
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[req.URI]; ok && time.Now().Before(e.expires) {
+		c.mu.Unlock()
+		return copyResponse(e.resp), nil
+	}
+	f, ok := c.flights[req.URI]
+	if !ok {
+		f = &flight{done: make(chan struct{})}
+		c.flights[req.URI] = f
+		go w.fly(ctx, c, f, req, ttl, c.generation)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return copyResponse(f.resp), f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fly sends the GET of f and caches its response. It does not stop when the
+// caller that started it gives up, the others may still be waiting for it,
+// but is bounded by the request timeout.
+func (w *wowza) fly(ctx context.Context, c *responseCache, f *flight, req *helper.Request, ttl time.Duration, generation uint64) {
+	ctx, cancel := w.flightContext(ctx)
+	defer cancel()
+	f.resp, f.err = w.send(ctx, req)
+
+	c.mu.Lock()
+	delete(c.flights, req.URI)
+	if f.err == nil && checkResponse(req.Method, req.URI, f.resp.StatusCode, f.resp.Body) == nil && generation == c.generation {
+		c.entries[req.URI] = cacheEntry{resp: f.resp, expires: time.Now().Add(ttl)}
+	}
+	c.mu.Unlock()
+	close(f.done)
+}
+
+// flightContext returns a context keeping the values of ctx but not its
+// cancellation, expiring once every attempt allowed by the retry policy
+// could have used the request timeout
+func (w *wowza) flightContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := w.requestTimeout(ctx)
+	ctx = context.WithoutCancel(ctx)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	if p := w.settings.RetryPolicy(); p.Allows(GET.String()) {
+		timeout = time.Duration(p.MaxAttempts)*timeout + time.Duration(p.MaxAttempts-1)*p.MaxBackoff
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// invalidate drops the responses cached for the paths above and below uri,
+// everything when uri is empty
+func (c *responseCache) invalidate(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	if uri == "" {
+		c.entries = make(map[string]cacheEntry)
+		return
+	}
+	path := cachePath(uri)
+	for key := range c.entries {
+		cached := cachePath(key)
+		if strings.HasPrefix(cached, path) || strings.HasPrefix(path, cached) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cachePath returns the host and path of uri, without the query, ending
+// with a slash so that prefixes match whole segments
+func cachePath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	return u.Host + strings.TrimSuffix(u.Path, "/") + "/"
+}
+
+// copyResponse returns a copy of resp that callers may modify
+func copyResponse(resp *helper.Response) *helper.Response {
+	if resp == nil {
+		return nil
+	}
+	r := *resp
+	r.Header = resp.Header.Clone()
+	r.Body = bytes.Clone(resp.Body)
+	return &r
+}
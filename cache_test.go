@@ -0,0 +1,167 @@
+package wserest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application"
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestCache(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			atomic.AddInt32(&calls, 1)
+			<-release
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetCache(&helper.Cache{
+		TTL:          time.Minute,
+		ResourceTTLs: map[string]time.Duration{"monitoring": 0},
+	})
+	app := NewApplication(settings, "live", "", "", "", "")
+	get := func() {
+		if _, err := app.Get(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get()
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("expected concurrent GETs to be collapsed, got %d calls", calls)
+	}
+
+	get()
+	if calls != 1 {
+		t.Fatalf("expected the response to be cached, got %d calls", calls)
+	}
+
+	NewApplication(settings, "other", "", "", "", "").Get()
+	if calls != 2 {
+		t.Fatalf("expected responses to be cached per URI, got %d calls", calls)
+	}
+
+	if _, err := app.UpdateAdvanced(&application.AdvancedSettings{}, application.NewModules()); err != nil {
+		t.Fatal(err)
+	}
+	get()
+	if calls != 3 {
+		t.Fatalf("expected a PUT below the path to invalidate it, got %d calls", calls)
+	}
+	NewApplication(settings, "other", "", "", "", "").Get()
+	if calls != 3 {
+		t.Fatalf("expected other paths to stay cached, got %d calls", calls)
+	}
+
+	stats := NewStatistics(settings)
+	stats.GetApplicationStatistics(app)
+	stats.GetApplicationStatistics(app)
+	if calls != 5 {
+		t.Fatalf("expected resources with a zero TTL not to be cached, got %d calls", calls)
+	}
+
+	PurgeCache(settings)
+	get()
+	if calls != 6 {
+		t.Fatalf("expected the purged cache to be empty, got %d calls", calls)
+	}
+}
+
+func TestCacheLeaderCanceled(t *testing.T) {
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		w.Header().Set("X-Served", "1")
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetCache(&helper.Cache{TTL: time.Minute})
+	var responses []*helper.Response
+	var mu sync.Mutex
+	settings.Use(func(next helper.Handler) helper.Handler {
+		return func(ctx context.Context, req *helper.Request) (*helper.Response, error) {
+			resp, err := next(ctx, req)
+			if err == nil {
+				mu.Lock()
+				responses = append(responses, resp)
+				mu.Unlock()
+			}
+			return resp, err
+		}
+	})
+	app := NewApplication(settings, "live", "", "", "", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leader := make(chan error)
+	go func() {
+		_, err := app.GetWithContext(ctx)
+		leader <- err
+	}()
+	<-started
+	waiter := make(chan error)
+	go func() {
+		_, err := app.Get()
+		waiter <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// the caller sending the request gives up, the other one still waits
+	cancel()
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the leader to be canceled, got %v", err)
+	}
+	close(release)
+	if err := <-waiter; err != nil {
+		t.Fatalf("expected the waiter to get the response, got %v", err)
+	}
+	if _, err := app.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected a single call, got %d", atomic.LoadInt32(&calls))
+	}
+
+	// the responses share nothing with the cached one
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(responses))
+	}
+	responses[0].Header.Set("X-Served", "modified")
+	responses[0].Body[0] = '['
+	if _, err := app.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if got := responses[2].Header.Get("X-Served"); got != "1" || responses[2].Body[0] != '{' {
+		t.Fatalf("expected the cached response to be unchanged, got %q and %s", got, responses[2].Body)
+	}
+}
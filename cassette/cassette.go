@@ -0,0 +1,273 @@
+// Package cassette provides a RoundTripper recording the interactions with a
+// Wowza Streaming Engine REST API to a file and replaying them, so that
+// tests can run without a server.
+//
+//	rec, err := cassette.New("testdata/application.json", cassette.ModeAuto, nil)
+//	settings.SetTransport(rec)
+//	...
+//	err = rec.Save()
+//
+// Credentials, secret fields and digest nonces are scrubbed before they are
+// written.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sebastien4/wse-rest-library-go/redact"
+)
+
+// Mode tells whether a Recorder records or replays
+type Mode int
+
+const (
+	// ModeReplay answers the requests from the cassette, failing on the
+	// requests it does not hold
+	ModeReplay Mode = iota
+	// ModeRecord sends the requests and records the interactions
+	ModeRecord
+	// ModeAuto replays when the cassette file exists and records otherwise
+	ModeAuto
+)
+
+// challengeParamRegex matches the digest parameters identifying a session
+var challengeParamRegex = regexp.MustCompile(`(?i)\b(nonce|opaque)=("[^"]*"|[^,\s]*)`)
+
+// Request is a recorded request
+type Request struct {
+	Method string      `json:"method"`
+	URI    string      `json:"uri"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction is a request and the response it got
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the content of a cassette file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is a RoundTripper recording or replaying a cassette
+type Recorder struct {
+	mu       sync.Mutex
+	path     string
+	mode     Mode
+	next     http.RoundTripper
+	cassette Cassette
+	used     []bool
+	nonces   map[string]string
+}
+
+// New creates a Recorder for the cassette file at path. When recording, the
+// requests are sent with next, http.DefaultTransport when nil.
+func New(path string, mode Mode, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	r := &Recorder{path: path, mode: mode, next: next, nonces: make(map[string]string)}
+
+	if mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode == ModeReplay {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err = json.Unmarshal(b, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to decode cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// Mode returns whether the recorder records or replays
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Interactions returns the interactions recorded or loaded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// RoundTrip records or replays req
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r.mode == ModeReplay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.ContentLength = int64(len(body))
+	resp, err := r.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: Request{
+			Method: req.Method,
+			URI:    requestURI(req),
+			Header: scrubHeader(redact.RequestHeader(req)),
+			Body:   redact.Body(body),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     r.scrubResponseHeader(resp.Header),
+			Body:       redact.Body(respBody),
+		},
+	})
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	uri := requestURI(req)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || in.Request.Method != req.Method || in.Request.URI != uri {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette %s: no recorded interaction left for %s %s", r.path, req.Method, uri)
+}
+
+// Save writes the recorded interactions to the cassette file, it does
+// nothing when replaying
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err = os.WriteFile(r.path, append(b, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Unused returns the interactions that were not replayed, to check that a
+// test made every request it recorded
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, used := range r.used {
+		if !used {
+			unused = append(unused, r.cassette.Interactions[i])
+		}
+	}
+	return unused
+}
+
+// ErrUnused is returned by Check when interactions were not replayed
+var ErrUnused = errors.New("cassette: recorded interactions were not replayed")
+
+// Check returns ErrUnused when some interactions were not replayed
+func (r *Recorder) Check() error {
+	if unused := r.Unused(); len(unused) > 0 {
+		return fmt.Errorf("%w: %d left, next is %s %s", ErrUnused, len(unused), unused[0].Request.Method, unused[0].Request.URI)
+	}
+	return nil
+}
+
+// requestURI returns the path and query of req, the host is left out so
+// that a cassette recorded against one server replays against any
+func requestURI(req *http.Request) string {
+	return req.URL.RequestURI()
+}
+
+func scrubHeader(header http.Header) http.Header {
+	h := redact.Header(header)
+	h.Del("Date")
+	h.Del("Traceparent")
+	return h
+}
+
+// scrubResponseHeader scrubs the secret headers and replaces the nonces of
+// the digest challenges with stable placeholders
+func (r *Recorder) scrubResponseHeader(header http.Header) http.Header {
+	h := scrubHeader(header)
+	for i, challenge := range h.Values("WWW-Authenticate") {
+		h["Www-Authenticate"][i] = challengeParamRegex.ReplaceAllStringFunc(challenge, func(param string) string {
+			m := challengeParamRegex.FindStringSubmatch(param)
+			value := strings.Trim(m[2], `"`)
+			placeholder, ok := r.nonces[value]
+			if !ok {
+				placeholder = fmt.Sprintf("%s-%d", strings.ToLower(m[1]), len(r.nonces)+1)
+				r.nonces[value] = placeholder
+			}
+			return fmt.Sprintf(`%s="%s"`, m[1], placeholder)
+		})
+	}
+	return h
+}
@@ -0,0 +1,124 @@
+package cassette_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	wserest "github.com/sebastien4/wse-rest-library-go"
+	"github.com/sebastien4/wse-rest-library-go/cassette"
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// challengeServer asks for digest credentials once, then accepts them
+func challengeServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "Digest ") {
+			w.Header().Set("WWW-Authenticate", `Digest realm="Wowza", qop="auth", nonce="a8f3c1d9e2", opaque="77ff"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"success":true,"path":"` + r.URL.Path + `","drmConfig":{"ezDRMPassword":"drm-secret"}}`))
+	}))
+}
+
+func run(t *testing.T, rec *cassette.Recorder, host string) []map[string]interface{} {
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(host + "/v2")
+	settings.SetTransport(rec)
+	settings.SetAuthenticator(wserest.NewDigestAuthenticator("admin", "admin-secret"))
+
+	var responses []map[string]interface{}
+	response, err := wserest.NewApplication(settings, "live", "", "", "", "").Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses = append(responses, response)
+	response, err = wserest.NewUser(settings, "bob").Create("user-secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(responses, response)
+}
+
+func TestRecordReplay(t *testing.T) {
+	ts := challengeServer()
+	path := filepath.Join(t.TempDir(), "cassettes", "application.json")
+
+	rec, err := cassette.New(path, cassette.ModeAuto, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != cassette.ModeRecord {
+		t.Fatal("expected a missing cassette to be recorded")
+	}
+	recorded := run(t, rec, ts.URL)
+	if err = rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+
+	b, _ := os.ReadFile(path)
+	for _, secret := range []string{"admin-secret", "user-secret", "drm-secret", "a8f3c1d9e2", "77ff", "Digest username"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("expected %s to be scrubbed from the cassette:\n%s", secret, b)
+		}
+	}
+	if len(rec.Interactions()) != 3 {
+		t.Fatalf("expected the challenge and 2 requests to be recorded, got %d", len(rec.Interactions()))
+	}
+
+	rec, err = cassette.New(path, cassette.ModeAuto, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != cassette.ModeReplay {
+		t.Fatal("expected an existing cassette to be replayed")
+	}
+	replayed := run(t, rec, "http://wse.invalid:8087")
+	for i := range recorded {
+		if replayed[i]["path"] != recorded[i]["path"] {
+			t.Errorf("expected %v, got %v", recorded[i], replayed[i])
+		}
+	}
+	if err = rec.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := helper.NewDefaultSettings()
+	settings.SetTransport(rec)
+	if _, err = wserest.NewApplication(settings, "live", "", "", "", "").Get(); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Fatalf("expected requests missing from the cassette to fail, got %v", err)
+	}
+}
+
+func TestRecordAuthenticatorHeaders(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "apikey.json")
+
+	rec, err := cassette.New(path, cassette.ModeRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetTransport(rec)
+	settings.SetAuthenticator(wserest.NewHeaderAuthenticator("X-Api-Key", "topsecret"))
+	if _, err = wserest.NewApplication(settings, "live", "", "", "", "").Get(); err != nil {
+		t.Fatal(err)
+	}
+	if err = rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := os.ReadFile(path)
+	if strings.Contains(string(b), "topsecret") || !strings.Contains(string(b), "X-Api-Key") {
+		t.Errorf("expected the header of the authenticator to be scrubbed from the cassette:\n%s", b)
+	}
+}
@@ -0,0 +1,158 @@
+package wserest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// ErrCircuitOpen is returned, wrapped, when a call is rejected because the
+// circuit breaker of its host is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of the circuit breaker of a host
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every request until the cool-down is over
+	CircuitOpen
+	// CircuitHalfOpen lets probe requests through to test the host
+	CircuitHalfOpen
+)
+
+func (c CircuitState) String() string {
+	switch c {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// hostBreaker enforces a helper.CircuitBreaker for one host
+type hostBreaker struct {
+	mu       sync.Mutex
+	config   helper.CircuitBreaker
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+func newHostBreaker(config helper.CircuitBreaker) *hostBreaker {
+	if config.FailureThreshold < 1 {
+		config.FailureThreshold = 1
+	}
+	if config.HalfOpenRequests < 1 {
+		config.HalfOpenRequests = 1
+	}
+	return &hostBreaker{config: config}
+}
+
+// breaker returns the breaker of the host of uri, nil when there is no
+// circuit breaker
+func (w *wowza) breaker(uri string) *hostBreaker {
+	config := w.settings.CircuitBreaker()
+	if config == nil {
+		return nil
+	}
+	b := w.settings.HostState(helper.CircuitBreakerState, hostOf(uri), func() interface{} {
+		return newHostBreaker(*config)
+	})
+	return b.(*hostBreaker)
+}
+
+// CircuitStates returns the state of the circuit breaker of each host
+// contacted with settings, for health reporting
+func CircuitStates(settings *helper.Settings) map[string]CircuitState {
+	states := make(map[string]CircuitState)
+	for host, b := range settings.HostStates(helper.CircuitBreakerState) {
+		states[host] = b.(*hostBreaker).State()
+	}
+	return states
+}
+
+// State returns the current state of the breaker
+func (b *hostBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.config.CoolDown {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a request may be sent to host
+func (b *hostBreaker) allow(host string) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		if time.Since(b.openedAt) < b.config.CoolDown {
+			return fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+		}
+		b.state = CircuitHalfOpen
+		b.probes = 0
+	}
+	if b.state == CircuitHalfOpen {
+		if b.probes >= b.config.HalfOpenRequests {
+			return fmt.Errorf("%s: %w", host, ErrCircuitOpen)
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// record updates the breaker with the outcome of a request
+func (b *hostBreaker) record(failed bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.state = CircuitClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.state = CircuitOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// abort releases the probe slot of a request canceled by the caller
+func (b *hostBreaker) abort() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// isHostFailure reports whether the outcome of a request shows the host is
+// unhealthy
+func isHostFailure(resp *helper.Response, err error) bool {
+	if err != nil {
+		return isNetworkError(err)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
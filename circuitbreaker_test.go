@@ -0,0 +1,79 @@
+package wserest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var healthy, calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetRetryPolicy(nil)
+	settings.SetCircuitBreaker(&helper.CircuitBreaker{FailureThreshold: 3, CoolDown: 50 * time.Millisecond})
+	app := NewApplication(settings, "live", "", "", "", "")
+	state := func() CircuitState {
+		return CircuitStates(settings)[ts.URL]
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := app.Get(); StatusCode(err) != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503, got %v", err)
+		}
+	}
+	if state() != CircuitOpen {
+		t.Fatalf("expected the circuit to be open, got %s", state())
+	}
+	if _, err := NewStatistics(settings).GetApplicationStatistics(app); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected the open circuit to fail fast, got %d calls", calls)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if state() != CircuitHalfOpen {
+		t.Fatalf("expected the circuit to be half-open, got %s", state())
+	}
+	if _, err := app.Get(); StatusCode(err) != http.StatusServiceUnavailable {
+		t.Fatalf("expected the probe to fail, got %v", err)
+	}
+	if state() != CircuitOpen {
+		t.Fatalf("expected a failed probe to reopen the circuit, got %s", state())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	atomic.StoreInt32(&healthy, 1)
+	if _, err := app.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if state() != CircuitClosed {
+		t.Fatalf("expected a successful probe to close the circuit, got %s", state())
+	}
+
+	other := helper.NewDefaultSettings()
+	other.SetCircuitBreaker(settings.CircuitBreaker())
+	if len(CircuitStates(other)) != 0 {
+		t.Fatalf("expected the breakers to be kept by each Settings, got %v", CircuitStates(other))
+	}
+	settings.SetCircuitBreaker(helper.DefaultCircuitBreaker())
+	if len(CircuitStates(settings)) != 0 {
+		t.Fatalf("expected a new configuration to forget the breakers, got %v", CircuitStates(settings))
+	}
+}
@@ -2,6 +2,11 @@ package wserest
 
 import (
 	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/cassette"
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
 )
 
 var UseWowza = getenv("USE_WOWZA", "")
@@ -19,3 +24,29 @@ func getenv(key, fallback string) string {
 	}
 	return value
 }
+
+// useCassette sends the requests made with settings through the cassette
+// testdata/cassettes/name.json. They are replayed from it, or recorded
+// against the server at WOWZA_HOST when USE_WOWZA is set.
+func useCassette(t *testing.T, settings *helper.Settings, name string) {
+	mode := cassette.ModeReplay
+	if UseWowza != "" {
+		mode = cassette.ModeRecord
+	}
+	rec, err := cassette.New(filepath.Join("testdata", "cassettes", name+".json"), mode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.SetTransport(rec)
+	t.Cleanup(func() {
+		if t.Failed() {
+			return
+		}
+		if err := rec.Save(); err != nil {
+			t.Error(err)
+		}
+		if err := rec.Check(); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+package wserest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application"
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// echoServer answers every request with its method, path and decoded body
+func echoServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make(map[string]interface{})
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"query":  r.URL.RawQuery,
+			"body":   body,
+		})
+	}))
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	ts := echoServer(t)
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetUseDigest(true)
+
+	app := NewApplication(settings, "live", "", "", "", "")
+	stats := NewStatistics(settings)
+	recording := NewRecording(settings, "live", "")
+	streamFile := NewStreamFile(settings, "live", "camera")
+	const appPath = "/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/"
+
+	check := func(response map[string]interface{}, err error, method, path string) error {
+		if err != nil {
+			return err
+		}
+		if response["method"] != method || response["path"] != path {
+			return fmt.Errorf("expected %s %s, got %s %s", method, path, response["method"], response["path"])
+		}
+		body := response["body"].(map[string]interface{})
+		if body["restURI"] != ts.URL+path && body["restURI"] != ts.URL+path+"?"+response["query"].(string) {
+			return fmt.Errorf("expected restURI to match %s, got %s", path, body["restURI"])
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 400)
+	for i := 0; i < 50; i++ {
+		i := i
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			response, err := app.Get()
+			errs <- check(response, err, "GET", appPath+"live")
+		}()
+		go func() {
+			defer wg.Done()
+			response, err := app.Create(application.NewStreamConfig(), nil, nil, nil, nil, nil)
+			if err == nil && response["body"].(map[string]interface{})["name"] != "live" {
+				err = fmt.Errorf("expected application properties in Create body, got %v", response["body"])
+			}
+			errs <- check(response, err, "POST", appPath+"live")
+		}()
+		go func() {
+			defer wg.Done()
+			other := NewApplication(settings, fmt.Sprintf("app%d", i), "", "", "", "")
+			response, err := stats.GetApplicationStatistics(other)
+			errs <- check(response, err, "GET", appPath+fmt.Sprintf("app%d/monitoring/current", i))
+		}()
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("recorder%d", i)
+			response, err := recording.Stop(name)
+			errs <- check(response, err, "PUT", appPath+"live/instances/_definst_/streamrecorders/"+name+"/actions/stopRecording")
+			response, err = streamFile.Connect("")
+			errs <- check(response, err, "PUT", appPath+"live/streamfiles/camera/actions/connect")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestReconfigureWhileSending(t *testing.T) {
+	ts := echoServer(t)
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	app := NewApplication(settings, "live", "", "", "", "")
+
+	stop := make(chan struct{})
+	var sent int32
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, err := app.Get(); err != nil {
+					errs <- err
+					return
+				}
+				atomic.AddInt32(&sent, 1)
+			}
+		}()
+	}
+
+	// reconfigure until enough requests were sent meanwhile
+	for i := 0; atomic.LoadInt32(&sent) < 50; i++ {
+		settings.SetAuthenticator(NewBasicAuthenticator("admin", "admin"))
+		settings.SetTimeout(time.Duration(i+1) * time.Second)
+		settings.SetRetryPolicy(helper.DefaultRetryPolicy())
+		settings.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		settings.SetTracer(NewSpanRecorder())
+		settings.SetRateLimit(&helper.RateLimit{MaxInFlight: 2})
+		settings.SetCircuitBreaker(helper.DefaultCircuitBreaker())
+		settings.SetCache(&helper.Cache{TTL: time.Millisecond})
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
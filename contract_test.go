@@ -0,0 +1,365 @@
+package wserest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application"
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/contract")
+
+// contractHost is the host of the contract requests, it is never dialed
+const contractHost = "http://wse.test:8087/v2"
+
+// contractRequest is the golden form of a request
+type contractRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Query  url.Values      `json:"query,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// contractTransport records the requests and answers them with the
+// response fixture of the contract, or {"success":true} when it has none
+type contractTransport struct {
+	response []byte
+
+	mu       sync.Mutex
+	requests []contractRequest
+}
+
+func (c *contractTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := contractRequest{Method: req.Method, Path: req.URL.EscapedPath()}
+	if q := req.URL.Query(); len(q) > 0 {
+		r.Query = q
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(body) > 0 {
+			r.Body = body
+		}
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, r)
+	c.mu.Unlock()
+
+	response := c.response
+	if response == nil {
+		response = []byte(`{"success":true}`)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(response)),
+		Request:    req,
+	}, nil
+}
+
+// golden formats the recorded requests, json indents the bodies too
+func (c *contractTransport) golden() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, err := json.MarshalIndent(c.requests, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// matches reports whether the recorded requests are the golden ones, the
+// bodies are compared as JSON values so that the golden files can be written
+// by hand in any layout
+func (c *contractTransport) matches(golden []byte) (bool, error) {
+	var expected []contractRequest
+	if err := json.Unmarshal(golden, &expected); err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(expected) != len(c.requests) {
+		return false, nil
+	}
+	for i, r := range c.requests {
+		e := expected[i]
+		if r.Method != e.Method || r.Path != e.Path || !reflect.DeepEqual(r.Query, e.Query) {
+			return false, nil
+		}
+		var body, expectedBody interface{}
+		if len(r.Body) > 0 {
+			if err := json.Unmarshal(r.Body, &body); err != nil {
+				return false, err
+			}
+		}
+		if len(e.Body) > 0 {
+			if err := json.Unmarshal(e.Body, &expectedBody); err != nil {
+				return false, err
+			}
+		}
+		if !reflect.DeepEqual(body, expectedBody) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func TestContract(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(*helper.Settings) (interface{}, error)
+		want interface{}
+	}{
+		{
+			name: "application_create",
+			call: func(s *helper.Settings) (interface{}, error) {
+				securityConfig := application.NewSecurityConfig()
+				securityConfig.PublishAuthenticationMethod = "digest"
+				securityConfig.PlayAuthenticationMethod = "none"
+				modules := application.NewModules()
+				modules.ModuleList = append(modules.ModuleList, modules.GetModuleItem("ModuleCoreSecurity", "ModuleCoreSecurity", "com.wowza.wms.security.ModuleCoreSecurity", -1))
+				return NewApplication(s, "live", "Live", "", "", "").Create(application.NewStreamConfig(), securityConfig, modules, nil, nil, nil)
+			},
+		},
+		{
+			name: "application_update_advanced",
+			call: func(s *helper.Settings) (interface{}, error) {
+				item := helper.NewAdvancedSettingItem()
+				item.Name = "streamTimeout"
+				item.Value = "12000"
+				item.Type = "Integer"
+				item.Section = "/Root/Application"
+				return NewApplication(s, "live", "Live", "", "", "").UpdateAdvanced(
+					&application.AdvancedSettings{AdvancedSettings: []helper.AdvancedSettingItem{*item}},
+					application.NewModules())
+			},
+		},
+		{
+			name: "application_get_all",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewApplication(s, "live", "", "", "", "").GetAll()
+			},
+			want: WSEApps{
+				ServerName: "_defaultServer_",
+				Applications: []WSEApp{
+					{ID: "live", AppType: "Live", HREF: "/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/live", DVREnabled: true, TranscoderEnabled: true},
+					{ID: "vod", AppType: "VOD", HREF: "/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/vod"},
+				},
+			},
+		},
+		{
+			name: "recording_create",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewRecording(s, "live", "_definst_").Create("myStream", "_definst_", "Waiting for stream", true, "SegmentByDuration",
+					"/usr/local/WowzaStreamingEngine/content", "myStream.mp4", "MP4", "", "${SourceStreamName}_${SegmentNumber}",
+					900000, 10485760, "0 * * * * *", true, false, false, "Version existing file", true, 0, 0, "")
+			},
+		},
+		{
+			name: "smilfile_create",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewSmilFile(s, "vod").Create("myStream", []map[string]interface{}{
+					{"type": "video", "src": "mp4:sample_360.mp4", "systemLanguage": "en", "videoBitrate": "900000", "audioBitrate": "96000", "width": "640", "height": "360"},
+					{"type": "video", "src": "mp4:sample_720.mp4", "systemLanguage": "en", "videoBitrate": "2400000", "audioBitrate": "128000", "width": "1280", "height": "720"},
+				})
+			},
+		},
+		{
+			name: "streamtarget_create",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewStreamTarget(s, "live").Create("myStream", "ppsource", "rtmp", "rtmp.example.com", "publisher", "secret", "myStream", "live")
+			},
+		},
+		{
+			name: "streamfile_create",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewStreamFile(s, "live", "camera").Create(map[string]interface{}{"uri": "rtsp://camera.example.com/live"}, "rtp", "_definst_")
+			},
+		},
+		{
+			name: "publisher_create",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewPublisher(s, "encoder").Create("secret")
+			},
+		},
+		{
+			name: "user_create",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewUser(s, "bob").Create("secret", []string{"admin", "advUser"})
+			},
+		},
+		{
+			name: "server_create_user",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewServer(s).CreateUser("bob", "secret", []string{"admin"})
+			},
+		},
+		{
+			name: "server_get_users",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewServer(s).GetUsers()
+			},
+		},
+		{
+			name: "statistics_application",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewStatistics(s).GetApplicationStatistics(NewApplication(s, "live", "", "", "", ""))
+			},
+		},
+		{
+			name: "statistics_incoming_stream",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewStatistics(s).GetIncomingApplicationStatistics(NewApplication(s, "live", "", "", "", ""), "myStream", "")
+			},
+		},
+		{
+			name: "statistics_server",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewStatistics(s).GetServerStatistics(NewServer(s))
+			},
+		},
+		{
+			name: "logging_search",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewLogging(s).Search("error")
+			},
+		},
+		{
+			name: "logging_search_escaped",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewLogging(s).Search("app=live&stream=a b")
+			},
+		},
+		{
+			name: "streamfile_connect_escaped",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewStreamFile(s, "live", "my cam?1").Connect("site/a")
+			},
+		},
+		{
+			name: "user_remove_escaped",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewUser(s, "ops/admin").Remove()
+			},
+		},
+		{
+			name: "dvr_convert",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewDvrClipExtraction(s, "live", "").Convert("myStream.0", 1000, 61000, "/clips", "clip.mp4", false)
+			},
+		},
+		{
+			name: "dvr_get_all",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewDvrClipExtraction(s, "live", "").GetAll()
+			},
+			want: WSEDVRStores{
+				ServerName:               "_defaultServer_",
+				Version:                  "4.8.5",
+				DVRConverterStoreSummary: []WSEDVRStore{{ID: "myStream.0", Location: "/usr/local/WowzaStreamingEngine/dvr/_definst_/myStream.0"}},
+			},
+		},
+		{
+			name: "dvr_get_item",
+			call: func(s *helper.Settings) (interface{}, error) {
+				return NewDvrClipExtraction(s, "live", "").GetItem("myStream.0")
+			},
+			want: WSEDVRConverter{
+				ID:         "myStream.0",
+				ServerName: "_defaultServer_",
+				Version:    "4.8.5",
+				DVRConverterStore: WSEDVRConverterStore{
+					DVRStoreName:   "myStream.0",
+					AudioAvailable: true,
+					VideoAvailable: true,
+					IsLive:         true,
+					DVRStartTime:   0,
+					DVREndTime:     3600000,
+					Duration:       3600000,
+					UTCStart:       1700000000000,
+					UTCEnd:         1700003600000,
+					OutputFilename: "clip.mp4",
+					DVRConversionStatus: WSEDVRConversionStatus{
+						StoreName:    "myStream.0",
+						FileName:     "clip.mp4",
+						State:        "SUCCESSFUL",
+						StatusCode:   "200",
+						ErrorStrings: []string{},
+						StartTime:    1000,
+						EndTime:      61000,
+						Duration:     60000,
+						CurrentChunk: 30,
+						ChunkCount:   30,
+						FileSize:     7340032,
+						FileDuration: 60000,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := new(contractTransport)
+			response, err := os.ReadFile(filepath.Join("testdata", "contract", tt.name+".response.json"))
+			if err == nil {
+				transport.response = response
+			} else if !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+
+			settings := helper.NewDefaultSettings()
+			settings.SetHost(contractHost)
+			settings.SetTransport(transport)
+
+			got, err := tt.call(settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+
+			actual, err := transport.golden()
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "contract", tt.name+".golden.json")
+			if *update {
+				// the diff of the rewritten files is to be checked against
+				// the WSE REST API reference
+				if err = os.WriteFile(golden, actual, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("wrote %s, check it against the WSE REST API reference", golden)
+				return
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v, run go test -run TestContract -update to write it", err)
+			}
+			ok, err := transport.matches(expected)
+			if err != nil {
+				t.Fatalf("invalid golden file %s: %v", golden, err)
+			}
+			if !ok {
+				t.Errorf("requests differ from %s, it follows the WSE REST API reference and is only rewritten when the API changes\ngot:\n%s\nwant:\n%s",
+					golden, actual, strings.TrimSpace(string(expected)))
+			}
+		})
+	}
+}
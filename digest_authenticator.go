@@ -0,0 +1,102 @@
+package wserest
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// DigestAuthenticator answers Digest challenges (RFC 7616) and keeps the last
+// nonce of each protection space so that following requests are
+// authenticated preemptively, without a 401 round trip
+type DigestAuthenticator struct {
+	username string
+	password string
+	cache    *digestCache
+}
+
+type digestCache struct {
+	mu      sync.Mutex
+	entries map[string]*digestEntry
+}
+
+type digestEntry struct {
+	wa   *wwwAuthenticate
+	auth *authorization
+}
+
+// sharedDigestCache is used when helper.Settings only enables digest
+var sharedDigestCache = newDigestCache()
+
+func newDigestCache() *digestCache {
+	return &digestCache{entries: make(map[string]*digestEntry)}
+}
+
+// NewDigestAuthenticator creates a DigestAuthenticator with its own nonce
+// cache
+func NewDigestAuthenticator(username, password string) *DigestAuthenticator {
+	return newDigestAuthenticator(username, password, newDigestCache())
+}
+
+func newDigestAuthenticator(username, password string, cache *digestCache) *DigestAuthenticator {
+	return &DigestAuthenticator{
+		username: username,
+		password: password,
+		cache:    cache,
+	}
+}
+
+// key identifies the protection space of req for this user
+func (d *DigestAuthenticator) key(req *http.Request) string {
+	return req.URL.Scheme + "://" + req.URL.Host + "\x00" + d.username
+}
+
+// Authorize sets the Authorization header of req when a challenge is known
+// for its protection space
+func (d *DigestAuthenticator) Authorize(req *http.Request, body []byte) error {
+	d.cache.mu.Lock()
+	defer d.cache.mu.Unlock()
+
+	entry, ok := d.cache.entries[d.key(req)]
+	if !ok {
+		return nil
+	}
+
+	dr := new(digestRequest)
+	dr.UpdateRequest(d.username, d.password, req.Method, req.URL.String(), string(body))
+	dr.Wa = entry.wa
+
+	var err error
+	if entry.auth == nil {
+		entry.auth, err = newAuthorization(dr)
+	} else {
+		_, err = entry.auth.refreshAuthorization(dr)
+	}
+	if err != nil {
+		delete(d.cache.entries, d.key(req))
+		return err
+	}
+	req.Header.Set("Authorization", entry.auth.toString())
+	return nil
+}
+
+// Challenge records the Digest challenge carried by a 401 response and
+// reports whether the request should be sent again
+func (d *DigestAuthenticator) Challenge(resp *http.Response) (bool, error) {
+	if resp.Header.Get("WWW-Authenticate") == "" {
+		return false, fmt.Errorf("failed to get WWW-Authenticate header, please check your server configuration")
+	}
+	wa := bestDigestChallenge(parseDigestChallenges(resp.Header))
+	if wa == nil {
+		return false, nil
+	}
+
+	sent := resp.Request.Header.Get("Authorization")
+	retry := sent == "" || wa.Stale || newWwwAuthenticate(sent).Nonce != wa.Nonce
+
+	d.cache.mu.Lock()
+	d.cache.entries[d.key(resp.Request)] = &digestEntry{wa: wa}
+	d.cache.mu.Unlock()
+
+	return retry, nil
+}
@@ -0,0 +1,190 @@
+package wserest
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+	"github.com/sebastien4/wse-rest-library-go/httpauth"
+)
+
+// digestServer is a minimal RFC 7616 server used to exercise the client
+type digestServer struct {
+	mu         sync.Mutex
+	algorithm  string
+	qop        string
+	nonce      int
+	challenges int
+	requests   int
+	lastNc     string
+}
+
+func (ds *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.requests++
+
+	params := map[string]string{}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Digest ") {
+		if c, err := httpauth.ParseChallenges(auth); err == nil && len(c) == 1 {
+			params = c[0].Params
+		}
+	}
+	nonce := fmt.Sprintf("nonce-%d", ds.nonce)
+	body, _ := io.ReadAll(r.Body)
+	if params["nonce"] == nonce && params["response"] == ds.expected(r, params, body) {
+		ds.lastNc = params["nc"]
+		w.Write([]byte(`{"success":true}`))
+		return
+	}
+
+	stale := ""
+	if params["nonce"] != "" && params["nonce"] != nonce {
+		stale = ", stale=true"
+	}
+	ds.challenges++
+	w.Header().Add("WWW-Authenticate", `Basic realm="Wowza"`)
+	w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Digest realm="Wowza", qop="%s", algorithm=%s, nonce="%s", opaque="xyz"%s`, ds.qop, ds.algorithm, nonce, stale))
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+func (ds *digestServer) expected(r *http.Request, p map[string]string, body []byte) string {
+	var h func() hash.Hash
+	switch strings.TrimSuffix(ds.algorithm, "-sess") {
+	case "SHA-256":
+		h = sha256.New
+	case "SHA-512-256":
+		h = sha512.New512_256
+	default:
+		h = md5.New
+	}
+	sum := func(s string) string {
+		x := h()
+		x.Write([]byte(s))
+		return hex.EncodeToString(x.Sum(nil))
+	}
+	ha1 := sum("admin:Wowza:secret")
+	if strings.HasSuffix(ds.algorithm, "-sess") {
+		ha1 = sum(ha1 + ":" + p["nonce"] + ":" + p["cnonce"])
+	}
+	ha2 := sum(r.Method + ":" + p["uri"])
+	if p["qop"] == "auth-int" {
+		ha2 = sum(r.Method + ":" + p["uri"] + ":" + sum(string(body)))
+	}
+	return sum(ha1 + ":" + p["nonce"] + ":" + p["nc"] + ":" + p["cnonce"] + ":" + p["qop"] + ":" + ha2)
+}
+
+func newDigestTestSettings(url string) *helper.Settings {
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(url + "/v2")
+	settings.SetUseDigest(true)
+	settings.SetUsername("admin")
+	settings.SetPassword("secret")
+	return settings
+}
+
+func TestDigestAlgorithms(t *testing.T) {
+	for _, algorithm := range []string{"MD5", "MD5-sess", "SHA-256", "SHA-256-sess", "SHA-512-256", "SHA-512-256-sess"} {
+		ds := &digestServer{algorithm: algorithm, qop: "auth"}
+		ts := httptest.NewServer(ds)
+
+		if _, err := NewApplication(newDigestTestSettings(ts.URL), "live", "", "", "", "").Get(); err != nil {
+			t.Errorf("%s: %v", algorithm, err)
+		}
+		ts.Close()
+	}
+}
+
+func TestDigestAuthInt(t *testing.T) {
+	for _, algorithm := range []string{"MD5", "SHA-256-sess"} {
+		ds := &digestServer{algorithm: algorithm, qop: "auth-int"}
+		ts := httptest.NewServer(ds)
+
+		app := NewApplication(newDigestTestSettings(ts.URL), "live", "Live", "", "", "")
+		for i := 0; i < 2; i++ {
+			if _, err := app.Create(nil, nil, nil, nil, nil, nil); err != nil {
+				t.Errorf("%s: %v", algorithm, err)
+			}
+		}
+		if ds.challenges != 1 {
+			t.Errorf("%s: expected the body digest to be accepted with the reused nonce, got %d challenges", algorithm, ds.challenges)
+		}
+		ts.Close()
+	}
+}
+
+func TestDigestNonceReuse(t *testing.T) {
+	ds := &digestServer{algorithm: "SHA-256", qop: "auth"}
+	ts := httptest.NewServer(ds)
+	defer ts.Close()
+
+	app := NewApplication(newDigestTestSettings(ts.URL), "live", "", "", "", "")
+	for i := 0; i < 3; i++ {
+		if _, err := app.Get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ds.challenges != 1 || ds.requests != 4 {
+		t.Fatalf("expected a single challenge, got %d challenges for %d requests", ds.challenges, ds.requests)
+	}
+	if ds.lastNc != "00000003" {
+		t.Fatalf("expected nonce count to be incremented, got %s", ds.lastNc)
+	}
+
+	// the server rotates its nonce, the client must answer the stale challenge
+	ds.nonce++
+	if _, err := app.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if ds.challenges != 2 || ds.lastNc != "00000001" {
+		t.Fatalf("expected stale nonce to be renewed, got %d challenges and nc %s", ds.challenges, ds.lastNc)
+	}
+}
+
+func TestDigestWrongPassword(t *testing.T) {
+	ds := &digestServer{algorithm: "MD5", qop: "auth"}
+	ts := httptest.NewServer(ds)
+	defer ts.Close()
+
+	settings := newDigestTestSettings(ts.URL)
+	settings.SetPassword("wrong")
+	_, err := NewApplication(settings, "live", "", "", "", "").Get()
+	if !IsUnauthorized(err) {
+		t.Fatalf("expected unauthorized, got %v", err)
+	}
+	if ds.requests > maxChallenges+1 {
+		t.Fatalf("expected at most %d requests, got %d", maxChallenges+1, ds.requests)
+	}
+}
+
+func TestParseChallenges(t *testing.T) {
+	got, err := httpauth.ParseChallenges(`Basic realm="a, b", Digest realm="Wowza", qop="auth,auth-int", nonce="n", Bearer`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schemes []string
+	for _, c := range got {
+		schemes = append(schemes, c.Scheme)
+	}
+	if strings.Join(schemes, "|") != "Basic|Digest|Bearer" {
+		t.Fatalf("got schemes %q", schemes)
+	}
+	if got[0].Params["realm"] != "a, b" {
+		t.Fatalf("got Basic params %q", got[0].Params)
+	}
+
+	wa := digestParams(got[1])
+	if wa.Scheme != "Digest" || wa.Realm != "Wowza" || wa.Nonce != "n" || len(wa.qops()) != 2 {
+		t.Fatalf("unexpected challenge %+v", wa)
+	}
+}
@@ -0,0 +1,135 @@
+package wserest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sebastien4/wse-rest-library-go/redact"
+)
+
+// CapturedRequest is a request recorded by a DryRun, with its secrets
+// redacted
+type CapturedRequest struct {
+	Method string      `json:"method"`
+	URI    string      `json:"uri"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// DryRun is a RoundTripper capturing the requests instead of sending them,
+// each one is answered with a successful empty response. Install it to see
+// what an operation would send, whatever client or transport is configured:
+//
+//	dryRun := wserest.NewDryRun()
+//	settings.SetDryRun(dryRun)
+//	wserest.NewApplication(settings, "live", "Live", "", "", "").Create(...)
+//	fmt.Print(dryRun.Curl())
+type DryRun struct {
+	mu       sync.Mutex
+	requests []CapturedRequest
+}
+
+// NewDryRun create DryRun object
+func NewDryRun() *DryRun {
+	return new(DryRun)
+}
+
+// RoundTrip captures req and answers it with {"success":true}
+func (d *DryRun) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	c := CapturedRequest{
+		Method: req.Method,
+		URI:    req.URL.String(),
+		Header: redact.RequestHeader(req),
+	}
+	if len(body) > 0 {
+		c.Body = redact.Body(body)
+	}
+
+	d.mu.Lock()
+	d.requests = append(d.requests, c)
+	d.mu.Unlock()
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"success":true}`)),
+		Request:    req,
+	}, nil
+}
+
+// Requests returns the captured requests, in the order they were made
+func (d *DryRun) Requests() []CapturedRequest {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]CapturedRequest(nil), d.requests...)
+}
+
+// Reset forgets the captured requests
+func (d *DryRun) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requests = nil
+}
+
+// Plan renders the captured requests as an indented JSON array
+func (d *DryRun) Plan() ([]byte, error) {
+	requests := d.Requests()
+	if requests == nil {
+		requests = []CapturedRequest{}
+	}
+	return json.MarshalIndent(requests, "", "  ")
+}
+
+// Curl renders the captured requests as curl commands, one per line
+func (d *DryRun) Curl() string {
+	var b strings.Builder
+	for _, r := range d.Requests() {
+		b.WriteString(r.Curl())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Curl renders the request as a curl command
+func (r CapturedRequest) Curl() string {
+	args := []string{"curl", "-X", r.Method, shellQuote(r.URI)}
+
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		if name != "Content-Length" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range r.Header[name] {
+			args = append(args, "-H", shellQuote(name+": "+value))
+		}
+	}
+	if r.Body != "" {
+		args = append(args, "--data", shellQuote(r.Body))
+	}
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,181 @@
+package wserest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestDryRun(t *testing.T) {
+	dryRun := NewDryRun()
+	settings := helper.NewDefaultSettings()
+	settings.SetAuthenticator(NewBasicAuthenticator("admin", "s3cr3t"))
+	settings.SetDryRun(dryRun)
+
+	if _, err := NewStreamFile(settings, "live", "camera").Create(map[string]interface{}{"uri": "rtsp://o'hara/cam"}, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewUser(settings, "bob").Create("s3cr3t", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := dryRun.Requests()
+	if len(requests) != 3 || requests[0].Method != "POST" || requests[1].Method != "PUT" || !strings.HasSuffix(requests[1].URI, "/streamfiles/camera/adv") {
+		t.Fatalf("unexpected requests %+v", requests)
+	}
+
+	curl := dryRun.Curl()
+	if strings.Contains(curl, "s3cr3t") || strings.Contains(curl, "YWRtaW46czNjcjN0") {
+		t.Errorf("secrets leaked:\n%s", curl)
+	}
+	lines := strings.Split(strings.TrimSpace(curl), "\n")
+	expected := "curl -X PUT 'http://localhost:8087/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/live/streamfiles/camera/adv' -H 'Accept: application/json; charset=utf-8' -H 'Authorization: [REDACTED]' -H 'Content-Type: application/json; charset=utf-8' --data "
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], expected) || !strings.Contains(lines[1], `rtsp://o'\''hara/cam`) {
+		t.Errorf("unexpected curl commands:\n%s", curl)
+	}
+
+	plan, err := dryRun.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []CapturedRequest
+	if err = json.Unmarshal(plan, &decoded); err != nil || len(decoded) != 3 {
+		t.Fatalf("expected a JSON plan of 3 requests, got %s", plan)
+	}
+	if !strings.Contains(decoded[2].Body, `"password":"[REDACTED]"`) {
+		t.Errorf("expected the password to be redacted, got %s", decoded[2].Body)
+	}
+}
+
+// apiKeyAuthenticator sends an API key in a header that is not known to be
+// secret
+type apiKeyAuthenticator struct{}
+
+func (apiKeyAuthenticator) Authorize(req *http.Request, body []byte) error {
+	req.Header.Set("X-Api-Key", "topsecret")
+	return nil
+}
+
+func (apiKeyAuthenticator) Challenge(resp *http.Response) (bool, error) {
+	return false, nil
+}
+
+func TestDryRunAuthenticatorHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	dryRun := NewDryRun()
+	settings := helper.NewDefaultSettings()
+	settings.SetAuthenticator(apiKeyAuthenticator{})
+	settings.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	settings.SetDryRun(dryRun)
+
+	if _, err := NewApplication(settings, "live", "", "", "", "").Get(); err != nil {
+		t.Fatal(err)
+	}
+	plan, err := dryRun.Plan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, out := range map[string]string{"curl": dryRun.Curl(), "plan": string(plan), "log": buf.String()} {
+		if strings.Contains(out, "topsecret") || !strings.Contains(out, "[REDACTED]") {
+			t.Errorf("expected the header of the authenticator to be redacted from the %s:\n%s", name, out)
+		}
+	}
+}
+
+func TestDryRunClientOrdering(t *testing.T) {
+	sent := func(t *testing.T) http.RoundTripper {
+		return roundTripFunc(func(*http.Request) (*http.Response, error) {
+			t.Error("request sent during a dry run")
+			return nil, errors.New("sent")
+		})
+	}
+	for _, tt := range []struct {
+		name  string
+		setup func(*helper.Settings, *DryRun, http.RoundTripper)
+	}{
+		{"dry run then client", func(s *helper.Settings, d *DryRun, rt http.RoundTripper) {
+			s.SetDryRun(d)
+			s.SetHTTPClient(&http.Client{Transport: rt})
+		}},
+		{"client then dry run", func(s *helper.Settings, d *DryRun, rt http.RoundTripper) {
+			s.SetHTTPClient(&http.Client{Transport: rt})
+			s.SetDryRun(d)
+		}},
+		{"dry run then TLS options", func(s *helper.Settings, d *DryRun, _ http.RoundTripper) {
+			s.SetDryRun(d)
+			if err := s.SetTLSOptions(&helper.TLSOptions{}); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"TLS options then dry run", func(s *helper.Settings, d *DryRun, _ http.RoundTripper) {
+			if err := s.SetTLSOptions(&helper.TLSOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			s.SetDryRun(d)
+		}},
+		{"dry run then transport", func(s *helper.Settings, d *DryRun, rt http.RoundTripper) {
+			s.SetDryRun(d)
+			s.SetTransport(rt)
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dryRun := NewDryRun()
+			settings := helper.NewDefaultSettings()
+			// nothing listens on this host, a sent request fails
+			settings.SetHost("http://127.0.0.1:1/v2")
+			settings.SetRetryPolicy(nil)
+			tt.setup(settings, dryRun, sent(t))
+
+			if _, err := NewPublisher(settings, "camera").Create("secret"); err != nil {
+				t.Fatal(err)
+			}
+			if requests := dryRun.Requests(); len(requests) != 1 || requests[0].Method != "POST" {
+				t.Fatalf("expected the request to be captured, got %+v", requests)
+			}
+		})
+	}
+}
+
+func TestDryRunBypassesCache(t *testing.T) {
+	var calls int
+	settings := helper.NewDefaultSettings()
+	settings.SetTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"name":"live"}`)),
+			Request:    r,
+		}, nil
+	}))
+	settings.SetCache(&helper.Cache{TTL: time.Minute})
+	dryRun := NewDryRun()
+	settings.SetDryRun(dryRun)
+
+	app := NewApplication(settings, "live", "", "", "", "")
+	for i := 0; i < 2; i++ {
+		if _, err := app.Get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(dryRun.Requests()) != 2 {
+		t.Fatalf("expected every GET of the dry run to be captured, got %d", len(dryRun.Requests()))
+	}
+
+	settings.SetDryRun(nil)
+	got, err := app.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || got["name"] != "live" {
+		t.Fatalf("expected the dry run answers not to be cached, got %v after %d calls", got, calls)
+	}
+}
@@ -1,6 +1,7 @@
 package wserest
 
 import (
+	"context"
 	"strconv"
 	"strings"
 	"time"
@@ -9,7 +10,8 @@ import (
 	"github.com/sebastien4/wse-rest-library-go/entity/base"
 )
 
-// DvrClipExtraction is DVR stores utility
+// DvrClipExtraction is DVR stores utility, its calls fail with an error
+// wrapping ErrUnsupportedByServer on servers older than 4.1.0
 type DvrClipExtraction struct {
 	wowza
 }
@@ -74,287 +76,261 @@ func NewDvrClipExtraction(settings *helper.Settings, appName string, appInstance
 
 	d := new(DvrClipExtraction)
 	d.init(settings)
-	d.baseURI = d.host() + "/servers/" + d.serverInstance() + "/vhosts/" + d.vHostInstance() + "/applications/" + appName + "/instances/" + appInstance + "/dvrstores"
+	d.feature = featureDVRClipExtraction
+	d.baseURI = d.vhostURI().join("applications", appName, "instances", appInstance, "dvrstores").String()
 
 	return d
 }
 
 // Create creates a new DVR store
 func (d *DvrClipExtraction) Create() (map[string]interface{}, error) {
-	d.setRestURI(d.baseURI)
-	response, err := d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, POST, "")
+	return d.CreateWithContext(context.Background())
+}
+
+// CreateWithContext is like Create but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) CreateWithContext(ctx context.Context) (map[string]interface{}, error) {
+	response, err := d.sendRequest(ctx, requestProps(d.baseURI), []base.Entity{}, POST, "")
 
 	return response, err
 }
 
 // GetItemOld retrieves the information about a store/converter
 func (d *DvrClipExtraction) GetItemOld(name string) (map[string]interface{}, error) {
-	d.setRestURI(d.baseURI + "/" + name)
+	return d.GetItemOldWithContext(context.Background(), name)
+}
+
+// GetItemOldWithContext is like GetItemOld but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) GetItemOldWithContext(ctx context.Context, name string) (map[string]interface{}, error) {
+	restURI := d.subURI(name).String()
 
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return d.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // GetItem retrieves the information about a store/converter
 func (d *DvrClipExtraction) GetItem(name string) (WSEDVRConverter, error) {
-	d.setRestURI(d.baseURI + "/" + name)
+	return d.GetItemWithContext(context.Background(), name)
+}
+
+// GetItemWithContext is like GetItem but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) GetItemWithContext(ctx context.Context, name string) (WSEDVRConverter, error) {
+	restURI := d.subURI(name).String()
 
 	var r WSEDVRConverter
-	err := d.sendRequestSeb(&r, d.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	err := d.sendRequestSeb(ctx, &r, requestProps(restURI), []base.Entity{}, GET, "")
 	return r, err
 }
 
 // ConvertGroup convert group
 func (d *DvrClipExtraction) ConvertGroup(nameArr []string) (map[string]interface{}, error) {
-	d.setNoParams()
-	d.setRestURI(d.baseURI + "/actions/convert?dvrConverterStoreList=" + strings.Join(nameArr, ","))
+	return d.ConvertGroupWithContext(context.Background(), nameArr)
+}
 
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+// ConvertGroupWithContext is like ConvertGroup but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) ConvertGroupWithContext(ctx context.Context, nameArr []string) (map[string]interface{}, error) {
+	restURI := d.subURI("actions", "convert").with("dvrConverterStoreList", strings.Join(nameArr, ",")).String()
+
+	return d.sendRequest(ctx, requestProps(restURI), []base.Entity{}, PUT, "")
 }
 
 // Convert converts
 func (d *DvrClipExtraction) Convert(name string, startTime int64, endTime int64, outputFolder, outputFileName string, debugEnabled bool) (map[string]interface{}, error) {
-	d.setNoParams()
-	query := ""
+	return d.ConvertWithContext(context.Background(), name, startTime, endTime, outputFolder, outputFileName, debugEnabled)
+}
+
+// ConvertWithContext is like Convert but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) ConvertWithContext(ctx context.Context, name string, startTime int64, endTime int64, outputFolder, outputFileName string, debugEnabled bool) (map[string]interface{}, error) {
+	restURI := d.subURI(name, "actions", "convert")
 
 	if startTime != 0 {
-		query += "dvrConverterStartTime=" + strconv.FormatInt(startTime, 10)
+		restURI = restURI.with("dvrConverterStartTime", strconv.FormatInt(startTime, 10))
 	}
 
 	if endTime != 0 {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterEndTime=" + strconv.FormatInt(endTime, 10)
+		restURI = restURI.with("dvrConverterEndTime", strconv.FormatInt(endTime, 10))
 	}
 
 	if outputFolder != "" {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterDefaultFileDestination=" + outputFolder
+		restURI = restURI.with("dvrConverterDefaultFileDestination", outputFolder)
 	}
 
 	if outputFileName != "" {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterOutputFilename=" + outputFileName
-	}
-
-	if query != "" {
-		query += "&"
-	}
-	query += "dvrConverterDebugConversions=" + strconv.FormatBool(debugEnabled)
-
-	if len(query) > 0 {
-		query = "?" + query
+		restURI = restURI.with("dvrConverterOutputFilename", outputFileName)
 	}
 
-	d.setRestURI(d.baseURI + "/" + name + "/actions/convert" + query)
+	restURI = restURI.with("dvrConverterDebugConversions", strconv.FormatBool(debugEnabled))
 
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+	return d.sendRequest(ctx, requestProps(restURI.String()), []base.Entity{}, PUT, "")
 }
 
 // ClearCache clear cache
 func (d *DvrClipExtraction) ClearCache() (map[string]interface{}, error) {
-	d.setRestURI(d.baseURI + "/actions/expire")
+	return d.ClearCacheWithContext(context.Background())
+}
 
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+// ClearCacheWithContext is like ClearCache but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) ClearCacheWithContext(ctx context.Context) (map[string]interface{}, error) {
+	restURI := d.subURI("actions", "expire").String()
+
+	return d.sendRequest(ctx, requestProps(restURI), []base.Entity{}, PUT, "")
 }
 
 // DebugConversions converts
 func (d *DvrClipExtraction) DebugConversions(name string) (map[string]interface{}, error) {
-	d.setRestURI(d.baseURI + "/" + name + "/actions/convert?dvrConverterDebugConversions=true")
+	return d.DebugConversionsWithContext(context.Background(), name)
+}
 
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+// DebugConversionsWithContext is like DebugConversions but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) DebugConversionsWithContext(ctx context.Context, name string) (map[string]interface{}, error) {
+	restURI := d.subURI(name, "actions", "convert").with("dvrConverterDebugConversions", "true").String()
+
+	return d.sendRequest(ctx, requestProps(restURI), []base.Entity{}, PUT, "")
 }
 
 // ConvertByDurationWithStartTime conver by duration with start time
 func (d *DvrClipExtraction) ConvertByDurationWithStartTime(name string, startTime *time.Time, duration *time.Duration, outputFileName string) (map[string]interface{}, error) {
-	d.setNoParams()
-	query := ""
+	return d.ConvertByDurationWithStartTimeWithContext(context.Background(), name, startTime, duration, outputFileName)
+}
+
+// ConvertByDurationWithStartTimeWithContext is like ConvertByDurationWithStartTime but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) ConvertByDurationWithStartTimeWithContext(ctx context.Context, name string, startTime *time.Time, duration *time.Duration, outputFileName string) (map[string]interface{}, error) {
+	restURI := d.subURI(name, "actions", "convert")
 	if startTime != nil {
-		query += "dvrConverterStartTime=" + strconv.FormatInt(startTime.Unix(), 10)
+		restURI = restURI.with("dvrConverterStartTime", strconv.FormatInt(startTime.Unix(), 10))
 	}
 	if duration != nil {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterDuration=" + strconv.FormatInt(int64(*duration/time.Millisecond), 10)
+		restURI = restURI.with("dvrConverterDuration", strconv.FormatInt(int64(*duration/time.Millisecond), 10))
 	}
 	if outputFileName != "" {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterOutputFilename=" + outputFileName
+		restURI = restURI.with("dvrConverterOutputFilename", outputFileName)
 	}
-	if len(query) > 0 {
-		query = "?" + query
-	}
-
-	d.setRestURI(d.baseURI + "/" + name + "/actions/convert" + query)
 
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+	return d.sendRequest(ctx, requestProps(restURI.String()), []base.Entity{}, PUT, "")
 }
 
 // ConvertByDurationWithStartTimeSeb converts by duration with start time
 func (d *DvrClipExtraction) ConvertByDurationWithStartTimeSeb(name string, startTime int64, duration int64, outputFileName string, debugEnabled bool) (map[string]interface{}, error) {
-	d.setNoParams()
-	query := ""
+	return d.ConvertByDurationWithStartTimeSebWithContext(context.Background(), name, startTime, duration, outputFileName, debugEnabled)
+}
+
+// ConvertByDurationWithStartTimeSebWithContext is like ConvertByDurationWithStartTimeSeb but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) ConvertByDurationWithStartTimeSebWithContext(ctx context.Context, name string, startTime int64, duration int64, outputFileName string, debugEnabled bool) (map[string]interface{}, error) {
+	restURI := d.subURI(name, "actions", "convert")
 
 	if startTime != 0 {
-		query += "dvrConverterStartTime=" + strconv.FormatInt(startTime, 10)
+		restURI = restURI.with("dvrConverterStartTime", strconv.FormatInt(startTime, 10))
 	}
 
 	if duration != 0 {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterDuration=" + strconv.FormatInt(duration, 10)
+		restURI = restURI.with("dvrConverterDuration", strconv.FormatInt(duration, 10))
 	}
 
 	if outputFileName != "" {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterOutputFilename=" + outputFileName
+		restURI = restURI.with("dvrConverterOutputFilename", outputFileName)
 	}
 
-	if query != "" {
-		query += "&"
-	}
-	query += "dvrConverterDebugConversions=" + strconv.FormatBool(debugEnabled)
+	restURI = restURI.with("dvrConverterDebugConversions", strconv.FormatBool(debugEnabled))
 
-	if len(query) > 0 {
-		query = "?" + query
-	}
-
-	d.setRestURI(d.baseURI + "/" + name + "/actions/convert" + query)
-
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+	return d.sendRequest(ctx, requestProps(restURI.String()), []base.Entity{}, PUT, "")
 }
 
 // ConvertByDurationWithEndTime convert by duration with end time
 func (d *DvrClipExtraction) ConvertByDurationWithEndTime(name string, endTime *time.Time, duration *time.Duration, outputFileName string) (map[string]interface{}, error) {
-	d.setNoParams()
-	query := ""
+	return d.ConvertByDurationWithEndTimeWithContext(context.Background(), name, endTime, duration, outputFileName)
+}
+
+// ConvertByDurationWithEndTimeWithContext is like ConvertByDurationWithEndTime but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) ConvertByDurationWithEndTimeWithContext(ctx context.Context, name string, endTime *time.Time, duration *time.Duration, outputFileName string) (map[string]interface{}, error) {
+	restURI := d.subURI(name, "actions", "convert")
 	if endTime != nil {
-		query += "dvrConverterEndTime=" + strconv.FormatInt(endTime.Unix(), 10)
+		restURI = restURI.with("dvrConverterEndTime", strconv.FormatInt(endTime.Unix(), 10))
 	}
 	if duration != nil {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterDuration=" + strconv.FormatInt(int64(*duration/time.Millisecond), 10)
+		restURI = restURI.with("dvrConverterDuration", strconv.FormatInt(int64(*duration/time.Millisecond), 10))
 	}
 	if outputFileName != "" {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterOutputFilename=" + outputFileName
+		restURI = restURI.with("dvrConverterOutputFilename", outputFileName)
 	}
-	if len(query) > 0 {
-		query = "?" + query
-	}
-
-	d.setRestURI(d.baseURI + "/" + name + "/actions/convert" + query)
 
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+	return d.sendRequest(ctx, requestProps(restURI.String()), []base.Entity{}, PUT, "")
 }
 
 // ConvertOld converts
 func (d *DvrClipExtraction) ConvertOld(name string, startTime *time.Time, endTime *time.Time, outputFileName string) (map[string]interface{}, error) {
-	d.setNoParams()
-	query := ""
+	return d.ConvertOldWithContext(context.Background(), name, startTime, endTime, outputFileName)
+}
+
+// ConvertOldWithContext is like ConvertOld but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) ConvertOldWithContext(ctx context.Context, name string, startTime *time.Time, endTime *time.Time, outputFileName string) (map[string]interface{}, error) {
+	restURI := d.subURI(name, "actions", "convert")
 	if startTime != nil {
-		query += "dvrConverterStartTime=" + strconv.FormatInt(startTime.Unix(), 10)
+		restURI = restURI.with("dvrConverterStartTime", strconv.FormatInt(startTime.Unix(), 10))
 	}
 	if endTime != nil {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterEndTime=" + strconv.FormatInt(endTime.Unix(), 10)
+		restURI = restURI.with("dvrConverterEndTime", strconv.FormatInt(endTime.Unix(), 10))
 	}
 	if outputFileName != "" {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterOutputFilename=" + outputFileName
-	}
-	if len(query) > 0 {
-		query = "?" + query
+		restURI = restURI.with("dvrConverterOutputFilename", outputFileName)
 	}
 
-	d.setRestURI(d.baseURI + "/" + name + "/actions/convert" + query)
-
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+	return d.sendRequest(ctx, requestProps(restURI.String()), []base.Entity{}, PUT, "")
 }
 
 // ConvertByDurationWithEndTimeSeb convert by duration with end time
 func (d *DvrClipExtraction) ConvertByDurationWithEndTimeSeb(name string, endTime int64, duration int64, outputFileName string, debugEnabled bool) (map[string]interface{}, error) {
-	d.setNoParams()
-	query := ""
+	return d.ConvertByDurationWithEndTimeSebWithContext(context.Background(), name, endTime, duration, outputFileName, debugEnabled)
+}
+
+// ConvertByDurationWithEndTimeSebWithContext is like ConvertByDurationWithEndTimeSeb but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) ConvertByDurationWithEndTimeSebWithContext(ctx context.Context, name string, endTime int64, duration int64, outputFileName string, debugEnabled bool) (map[string]interface{}, error) {
+	restURI := d.subURI(name, "actions", "convert")
 
 	if endTime != 0 {
-		query += "dvrConverterEndTime=" + strconv.FormatInt(endTime, 10)
+		restURI = restURI.with("dvrConverterEndTime", strconv.FormatInt(endTime, 10))
 	}
 
 	if duration != 0 {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterDuration=" + strconv.FormatInt(duration, 10)
+		restURI = restURI.with("dvrConverterDuration", strconv.FormatInt(duration, 10))
 	}
 
 	if outputFileName != "" {
-		if query != "" {
-			query += "&"
-		}
-		query += "dvrConverterOutputFilename=" + outputFileName
+		restURI = restURI.with("dvrConverterOutputFilename", outputFileName)
 	}
 
-	if query != "" {
-		query += "&"
-	}
-	query += "dvrConverterDebugConversions=" + strconv.FormatBool(debugEnabled)
+	restURI = restURI.with("dvrConverterDebugConversions", strconv.FormatBool(debugEnabled))
 
-	if len(query) > 0 {
-		query = "?" + query
-	}
-
-	d.setRestURI(d.baseURI + "/" + name + "/actions/convert" + query)
-
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+	return d.sendRequest(ctx, requestProps(restURI.String()), []base.Entity{}, PUT, "")
 }
 
 // GetAllOld retrieves the list of DVR stores associated with this application instance
 func (d *DvrClipExtraction) GetAllOld() (map[string]interface{}, error) {
-	d.setNoParams()
-
-	d.setRestURI(d.baseURI)
+	return d.GetAllOldWithContext(context.Background())
+}
 
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+// GetAllOldWithContext is like GetAllOld but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) GetAllOldWithContext(ctx context.Context) (map[string]interface{}, error) {
+	return d.sendRequest(ctx, requestProps(d.baseURI), []base.Entity{}, GET, "")
 }
 
 // GetAll retrieves the list of Applications
 func (d *DvrClipExtraction) GetAll() (WSEDVRStores, error) {
-	d.setNoParams()
-
-	d.setRestURI(d.baseURI)
+	return d.GetAllWithContext(context.Background())
+}
 
+// GetAllWithContext is like GetAll but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) GetAllWithContext(ctx context.Context) (WSEDVRStores, error) {
 	var r WSEDVRStores
-	err := d.sendRequestSeb(&r, d.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	err := d.sendRequestSeb(ctx, &r, requestProps(d.baseURI), []base.Entity{}, GET, "")
 	return r, err
 }
 
-func (d *DvrClipExtraction) setNoParams() {
-
-}
-
 // Remove delete DVR store
 func (d *DvrClipExtraction) Remove(fileName string) (map[string]interface{}, error) {
-	d.setNoParams()
-	d.setRestURI(d.baseURI + "/" + fileName)
+	return d.RemoveWithContext(context.Background(), fileName)
+}
+
+// RemoveWithContext is like Remove but honors ctx for cancellation and deadlines
+func (d *DvrClipExtraction) RemoveWithContext(ctx context.Context, fileName string) (map[string]interface{}, error) {
+	restURI := d.subURI(fileName).String()
 
-	return d.sendRequest(d.preparePropertiesForRequest(), []base.Entity{}, DELETE, "")
+	return d.sendRequest(ctx, requestProps(restURI), []base.Entity{}, DELETE, "")
 }
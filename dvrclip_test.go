@@ -11,14 +11,13 @@ func TestDvrClip(t *testing.T) {
 	// Create settings
 	settings := helper.NewDefaultSettings()
 	settings.SetHost(WowzaHost)
+	settings.SetUseDigest(true)
 	settings.SetUsername(WowzaUsername)
 	settings.SetPassword(WowzaPassword)
 
 	sf := NewDvrClipExtraction(settings, "ndvr", "")
 
-	if UseWowza == "" {
-		return
-	}
+	useCassette(t, settings, "dvrclip")
 
 	response, err := sf.ClearCache()
 	if err != nil {
@@ -32,7 +31,8 @@ func TestDvrClip(t *testing.T) {
 	}
 	t.Log(response)
 
-	now := time.Now()
+	// fixed times keep the requests identical to the recorded ones
+	now := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)
 	duration := time.Duration(5) * time.Second
 	response, err = sf.ConvertByDurationWithEndTime("tmp123", &now, &duration, "")
 	if err != nil {
@@ -40,15 +40,15 @@ func TestDvrClip(t *testing.T) {
 	}
 	t.Log(response)
 
-	hourago := time.Now().Add(-1 * time.Hour)
+	hourago := now.Add(-1 * time.Hour)
 	response, err = sf.ConvertByDurationWithStartTime("tmp123", &hourago, &duration, "")
 	if err != nil {
 		t.Fatal(err)
 	}
 	t.Log(response)
 
-	now = time.Now()
-	response, err = sf.Convert("tmp127", &now, nil, "")
+	now = now.Add(time.Minute)
+	response, err = sf.ConvertOld("tmp127", &now, nil, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -60,21 +60,21 @@ func TestDvrClip(t *testing.T) {
 	}
 	t.Log(response)
 
-	response, err = sf.Convert("tmp123", nil, nil, "")
+	response, err = sf.ConvertOld("tmp123", nil, nil, "")
 	if err != nil {
 		t.Fatal(err)
 	}
 	t.Log(response)
 
-	response, err = sf.GetItem("tmp123")
+	converter, err := sf.GetItem("tmp123")
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log(response)
+	t.Log(converter)
 
-	response, err = sf.GetAll()
+	stores, err := sf.GetAll()
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log(response)
+	t.Log(stores)
 }
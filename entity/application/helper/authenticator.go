@@ -0,0 +1,14 @@
+package helper
+
+import "net/http"
+
+// Authenticator adds credentials to the requests sent to the REST API, the
+// headers it sets are redacted from logs, dry runs and cassettes
+type Authenticator interface {
+	// Authorize is called before each request is sent, body is the payload
+	// of req
+	Authorize(req *http.Request, body []byte) error
+	// Challenge is called with each 401 response and reports whether the
+	// request should be sent again
+	Challenge(resp *http.Response) (retry bool, err error)
+}
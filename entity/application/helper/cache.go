@@ -0,0 +1,26 @@
+package helper
+
+import "time"
+
+// Cache configures the cache of GET responses. Responses are cached per
+// URI and shared by every Settings holding the same Cache, a POST, PUT or
+// DELETE invalidates the cached responses of the paths above and below the
+// one it targets.
+type Cache struct {
+	// TTL is how long responses are kept, for the resources not listed in
+	// ResourceTTLs
+	TTL time.Duration
+	// ResourceTTLs overrides TTL for kinds of resources named after their
+	// path segment, such as "applications", "monitoring" or "pushpublish",
+	// 0 disables caching for that kind
+	ResourceTTLs map[string]time.Duration
+}
+
+// TTLFor returns how long the responses of the given kind of resource are
+// kept
+func (c *Cache) TTLFor(resource string) time.Duration {
+	if ttl, ok := c.ResourceTTLs[resource]; ok {
+		return ttl
+	}
+	return c.TTL
+}
@@ -0,0 +1,28 @@
+package helper
+
+import "time"
+
+// CircuitBreaker configures the breaker failing calls fast while a host is
+// unhealthy. Breakers are per host and kept by the Settings, they are read
+// once when the first request is sent.
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failures opening the
+	// circuit, at least 1
+	FailureThreshold int
+	// CoolDown is how long the circuit stays open before probe requests are
+	// let through
+	CoolDown time.Duration
+	// HalfOpenRequests is the number of probe requests sent while half-open,
+	// at least 1
+	HalfOpenRequests int
+}
+
+// DefaultCircuitBreaker returns a breaker opening after 5 consecutive
+// failures for 30 seconds
+func DefaultCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureThreshold: 5,
+		CoolDown:         30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
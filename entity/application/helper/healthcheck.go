@@ -0,0 +1,19 @@
+package helper
+
+import "time"
+
+// HealthCheck configures the probes of the hosts set with SetHosts. A host
+// that could not be reached is marked down and tried last, it is probed in
+// the background until it answers. While another host is active, the first
+// one is probed too and requests go back to it as soon as it answers.
+type HealthCheck struct {
+	// Interval is the time between two probes of a host, probes are started
+	// by the requests so an idle Settings probes nothing
+	Interval time.Duration
+}
+
+// DefaultHealthCheck returns a health check probing the hosts every 10
+// seconds
+func DefaultHealthCheck() *HealthCheck {
+	return &HealthCheck{Interval: 10 * time.Second}
+}
@@ -0,0 +1,40 @@
+package helper
+
+import (
+	"context"
+	"net/http"
+)
+
+// Request is a call to the REST API as seen by a Middleware
+type Request struct {
+	// Method is the HTTP method
+	Method string
+	// URI is the resolved URI, including the query string
+	URI string
+	// Header holds extra headers sent with the request
+	Header http.Header
+	// Body is the JSON payload
+	Body []byte
+}
+
+// Response is the answer of the REST API as seen by a Middleware
+type Response struct {
+	// StatusCode is the HTTP status of the last attempt
+	StatusCode int
+	// Header holds the response headers
+	Header http.Header
+	// Body is the raw response body
+	Body []byte
+	// Payload is the value the body was decoded into, nil when the call
+	// failed
+	Payload interface{}
+}
+
+// Handler performs a call to the REST API. The response may be returned
+// along with an error, for instance when the server answered with an error
+// status.
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware wraps the Handler performing every call, it may modify the
+// request before calling next and inspect or modify what next returns
+type Middleware func(next Handler) Handler
@@ -0,0 +1,16 @@
+package helper
+
+// RateLimit bounds the load put on a Wowza Streaming Engine server. The
+// limits are per host and kept by the Settings, they are read once when the
+// first request is sent.
+type RateLimit struct {
+	// RequestsPerSecond is the rate at which requests are sent, retries
+	// included, 0 means unlimited
+	RequestsPerSecond float64
+	// Burst is the number of requests that may be sent at once above the
+	// rate, at least 1
+	Burst int
+	// MaxInFlight is the number of requests awaiting a response at the same
+	// time, 0 means unlimited
+	MaxInFlight int
+}
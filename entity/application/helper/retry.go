@@ -0,0 +1,84 @@
+package helper
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy describes how requests failing with a transient error are
+// sent again
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// InitialBackoff is the upper bound of the delay before the first retry,
+	// it doubles with each following retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts, including the delay
+	// asked for by a Retry-After header
+	MaxBackoff time.Duration
+	// RetryableStatus lists the HTTP status codes that trigger a retry
+	RetryableStatus []int
+	// RetryPOST allows POST requests to be retried, they are not idempotent
+	// and may create a resource twice
+	RetryPOST bool
+}
+
+// DefaultRetryPolicy returns the policy used by new settings: 3 attempts
+// for GET, PUT and DELETE requests failing with a connection error, 408,
+// 429, 502, 503 or 504
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		RetryableStatus: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// Allows reports whether requests with the given method may be retried
+func (p *RetryPolicy) Allows(method string) bool {
+	if p == nil || p.MaxAttempts <= 1 {
+		return false
+	}
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return p.RetryPOST
+	}
+	return false
+}
+
+// IsRetryableStatus reports whether statusCode triggers a retry
+func (p *RetryPolicy) IsRetryableStatus(statusCode int) bool {
+	for _, code := range p.RetryableStatus {
+		if code == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the delay before the given retry, starting at 1. It is
+// drawn at random up to the exponential bound so that clients do not retry
+// in lockstep.
+func (p *RetryPolicy) Backoff(retry int) time.Duration {
+	bound := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || bound < p.MaxBackoff); i++ {
+		bound *= 2
+	}
+	if p.MaxBackoff > 0 && bound > p.MaxBackoff {
+		bound = p.MaxBackoff
+	}
+	if bound <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(bound) + 1))
+}
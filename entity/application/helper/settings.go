@@ -1,14 +1,80 @@
 package helper
 
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the timeout applied to each HTTP request when none is set
+const DefaultTimeout = 10 * time.Second
+
+var (
+	sharedTransportOnce sync.Once
+	sharedTransport     *http.Transport
+)
+
+// SharedTransport returns the pooled transport used by every Settings that
+// does not provide its own client or transport
+func SharedTransport() *http.Transport {
+	sharedTransportOnce.Do(func() {
+		sharedTransport = http.DefaultTransport.(*http.Transport).Clone()
+		sharedTransport.MaxIdleConnsPerHost = 16
+	})
+	return sharedTransport
+}
+
 // Settings holds settings
 type Settings struct {
 	debug          bool
 	host           string
+	fallbackHosts  []string
 	serverInstance string
 	vhostInstance  string
 	username       string
 	password       string
 	useDigest      bool
+
+	// mu guards the settings below, they may be changed while requests are
+	// being sent
+	mu             sync.Mutex
+	timeout        time.Duration
+	authenticator  Authenticator
+	retryPolicy    *RetryPolicy
+	middlewares    []Middleware
+	logger         *slog.Logger
+	tracer         Tracer
+	rateLimit      *RateLimit
+	circuitBreaker *CircuitBreaker
+	healthCheck    *HealthCheck
+	cache          *Cache
+	httpClient     *http.Client
+	activeHost     string
+	transport      http.RoundTripper
+	client         *http.Client
+	dryRun         http.RoundTripper
+	// serverVersions maps the hosts to their Wowza Streaming Engine version
+	serverVersions map[string]string
+	// hostStates holds the state kept for each host, such as its rate limiter
+	// or circuit breaker
+	hostStates map[hostStateKey]interface{}
+}
+
+// Kinds of the state kept for each host
+const (
+	// RateLimitState enforces the RateLimit
+	RateLimitState = "rateLimit"
+	// CircuitBreakerState enforces the CircuitBreaker
+	CircuitBreakerState = "circuitBreaker"
+	// HealthCheckState tells whether a host is up according to the
+	// HealthCheck
+	HealthCheckState = "healthCheck"
+)
+
+type hostStateKey struct {
+	kind string
+	host string
 }
 
 func NewSettings(
@@ -28,6 +94,8 @@ func NewSettings(
 	s.username = username
 	s.password = password
 	s.useDigest = useDigest
+	s.timeout = DefaultTimeout
+	s.retryPolicy = DefaultRetryPolicy()
 	return s
 }
 
@@ -60,7 +128,41 @@ func (s *Settings) Host() string {
 
 // SetHost set host.
 func (s *Settings) SetHost(host string) {
+	s.SetHosts(host)
+}
+
+// Hosts get the base URLs of the REST API, host first.
+func (s *Settings) Hosts() []string {
+	return append([]string{s.host}, s.fallbackHosts...)
+}
+
+// SetHosts set the base URLs under which the REST API of the same server is
+// reachable, such as one per network interface. Resources are built with the
+// first one and requests fail over to the others when it is unreachable.
+func (s *Settings) SetHosts(host string, fallbackHosts ...string) {
 	s.host = host
+	s.fallbackHosts = fallbackHosts
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.activeHost = ""
+}
+
+// ActiveHost get the base URL requests are sent to first, the last one that
+// answered.
+func (s *Settings) ActiveHost() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.activeHost == "" {
+		return s.host
+	}
+	return s.activeHost
+}
+
+// SetActiveHost set the base URL requests are sent to first.
+func (s *Settings) SetActiveHost(host string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.activeHost = host
 }
 
 // ServerInstance get serverInstance.
@@ -112,3 +214,291 @@ func (s *Settings) IsUseDigest() bool {
 func (s *Settings) SetUseDigest(useDigest bool) {
 	s.useDigest = useDigest
 }
+
+// Authenticator get the authenticator, nil means digest or no
+// authentication depending on IsUseDigest.
+func (s *Settings) Authenticator() Authenticator {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.authenticator
+}
+
+// SetAuthenticator set the authenticator used for every request, it takes
+// precedence over SetUseDigest.
+func (s *Settings) SetAuthenticator(authenticator Authenticator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authenticator = authenticator
+}
+
+// Timeout get the timeout applied to each HTTP request.
+func (s *Settings) Timeout() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.timeout
+}
+
+// SetTimeout set the timeout applied to each HTTP request, 0 disables it.
+func (s *Settings) SetTimeout(timeout time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeout = timeout
+}
+
+// RetryPolicy get the retry policy, nil means requests are never retried.
+func (s *Settings) RetryPolicy() *RetryPolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.retryPolicy
+}
+
+// SetRetryPolicy set the retry policy, nil disables retries.
+func (s *Settings) SetRetryPolicy(retryPolicy *RetryPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retryPolicy = retryPolicy
+}
+
+// Logger get the logger, nil means debug output to stderr when IsDebug is
+// set and no logging otherwise.
+func (s *Settings) Logger() *slog.Logger {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logger
+}
+
+// SetLogger set the logger receiving the calls made to the REST API, secrets
+// are redacted from what is logged.
+func (s *Settings) SetLogger(logger *slog.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logger = logger
+}
+
+// Tracer get the tracer, nil means no spans are opened.
+func (s *Settings) Tracer() Tracer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracer
+}
+
+// SetTracer set the tracer opening a span for each operation, named after the
+// resource method such as "Application.Get", and each HTTP request it makes.
+func (s *Settings) SetTracer(tracer Tracer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tracer = tracer
+}
+
+// RateLimit get the rate limit, nil means unlimited.
+func (s *Settings) RateLimit() *RateLimit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rateLimit
+}
+
+// SetRateLimit set the rate limit applied to each host, the requests already
+// counted are forgotten.
+func (s *Settings) SetRateLimit(rateLimit *RateLimit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimit = rateLimit
+	s.forgetHostStates(RateLimitState)
+}
+
+// CircuitBreaker get the circuit breaker, nil means it is disabled.
+func (s *Settings) CircuitBreaker() *CircuitBreaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.circuitBreaker
+}
+
+// SetCircuitBreaker set the circuit breaker applied to each host, the
+// failures already counted are forgotten.
+func (s *Settings) SetCircuitBreaker(circuitBreaker *CircuitBreaker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.circuitBreaker = circuitBreaker
+	s.forgetHostStates(CircuitBreakerState)
+}
+
+// HealthCheck get the health check of the hosts, nil means it is disabled.
+func (s *Settings) HealthCheck() *HealthCheck {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.healthCheck
+}
+
+// SetHealthCheck set the health check of the hosts set with SetHosts, the
+// hosts already marked down are forgotten.
+func (s *Settings) SetHealthCheck(healthCheck *HealthCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthCheck = healthCheck
+	s.forgetHostStates(HealthCheckState)
+}
+
+// Cache get the GET response cache, nil means it is disabled.
+func (s *Settings) Cache() *Cache {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cache
+}
+
+// SetCache set the GET response cache.
+func (s *Settings) SetCache(cache *Cache) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = cache
+}
+
+// ServerVersion get the Wowza Streaming Engine version learnt for host, empty
+// when unknown.
+func (s *Settings) ServerVersion(host string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.serverVersions[host]
+}
+
+// SetServerVersion set the Wowza Streaming Engine version learnt for host, an
+// empty version forgets it.
+func (s *Settings) SetServerVersion(host string, version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if version == "" {
+		delete(s.serverVersions, host)
+		return
+	}
+	if s.serverVersions == nil {
+		s.serverVersions = make(map[string]string)
+	}
+	s.serverVersions[host] = version
+}
+
+// HostState get the state of the given kind kept for host, created with
+// newState the first time.
+func (s *Settings) HostState(kind string, host string, newState func() interface{}) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := hostStateKey{kind: kind, host: host}
+	state, ok := s.hostStates[key]
+	if !ok {
+		if s.hostStates == nil {
+			s.hostStates = make(map[hostStateKey]interface{})
+		}
+		state = newState()
+		s.hostStates[key] = state
+	}
+	return state
+}
+
+// HostStates get the state of the given kind kept for each host.
+func (s *Settings) HostStates(kind string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states := make(map[string]interface{})
+	for key, state := range s.hostStates {
+		if key.kind == kind {
+			states[key.host] = state
+		}
+	}
+	return states
+}
+
+// forgetHostStates forgets the state of the given kind kept for every host,
+// s.mu must be held
+func (s *Settings) forgetHostStates(kind string) {
+	for key := range s.hostStates {
+		if key.kind == kind {
+			delete(s.hostStates, key)
+		}
+	}
+}
+
+// Use appends middlewares to the chain wrapping every call, the first one
+// registered is the outermost.
+func (s *Settings) Use(middlewares ...Middleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middlewares = append(s.middlewares[:len(s.middlewares):len(s.middlewares)], middlewares...)
+}
+
+// Middlewares get the middlewares registered with Use.
+func (s *Settings) Middlewares() []Middleware {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.middlewares
+}
+
+// Transport get the RoundTripper used by the default client, nil means the
+// shared pooled transport.
+func (s *Settings) Transport() http.RoundTripper {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.transport
+}
+
+// SetTransport set the RoundTripper used by the default client.
+func (s *Settings) SetTransport(transport http.RoundTripper) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transport = transport
+	s.client = nil
+}
+
+// SetTLSOptions set the transport used by the default client to one
+// connecting with the given TLS options, it replaces SetTransport.
+func (s *Settings) SetTLSOptions(options *TLSOptions) error {
+	config, err := options.Config()
+	if err != nil {
+		return err
+	}
+	transport := SharedTransport().Clone()
+	transport.TLSClientConfig = config
+	s.SetTransport(transport)
+	return nil
+}
+
+// SetHTTPClient set the client used for every request, it takes precedence
+// over SetTransport. Its Timeout should be left to zero and configured with
+// SetTimeout instead so that per-call timeouts keep working.
+func (s *Settings) SetHTTPClient(client *http.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.httpClient = client
+}
+
+// HTTPClient get the client shared by every resource created with these
+// settings.
+func (s *Settings) HTTPClient() *http.Client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.httpClient != nil {
+		return s.httpClient
+	}
+	if s.client == nil {
+		transport := s.transport
+		if transport == nil {
+			transport = SharedTransport()
+		}
+		s.client = &http.Client{Transport: transport}
+	}
+	return s.client
+}
+
+// DryRun get the RoundTripper capturing the requests instead of sending
+// them, nil when they are sent.
+func (s *Settings) DryRun() http.RoundTripper {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dryRun
+}
+
+// SetDryRun set the RoundTripper capturing the requests instead of sending
+// them, it takes precedence over SetHTTPClient, SetTransport and
+// SetTLSOptions whatever the order they are called in. nil sends them again.
+func (s *Settings) SetDryRun(dryRun http.RoundTripper) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dryRun = dryRun
+}
@@ -0,0 +1,94 @@
+package helper
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// TLSOptions configures the TLS connections to the REST API
+type TLSOptions struct {
+	// RootCAsPEM holds the PEM encoded certificates of the authorities
+	// trusted to sign the server certificate, the system pool is used when
+	// empty
+	RootCAsPEM []byte
+	// ClientCertificates are presented to servers requiring mutual TLS
+	ClientCertificates []tls.Certificate
+	// ServerName overrides the name checked against the server certificate
+	ServerName string
+	// PinnedSPKIHashes lists the base64 encoded SHA-256 hashes of the
+	// SubjectPublicKeyInfo of accepted certificates, one of the certificates
+	// of a verified chain must match when it is not empty, or the server
+	// certificate itself when InsecureSkipVerify is set
+	PinnedSPKIHashes []string
+	// MinVersion is the minimum TLS version, TLS 1.2 when 0
+	MinVersion uint16
+	// InsecureSkipVerify disables the verification of the server
+	// certificate, pinning still applies to the server certificate
+	InsecureSkipVerify bool
+}
+
+// AddClientCertificatePEM appends the client certificate made of the PEM
+// encoded certificate chain and private key
+func (o *TLSOptions) AddClientCertificatePEM(certPEM, keyPEM []byte) error {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	o.ClientCertificates = append(o.ClientCertificates, cert)
+	return nil
+}
+
+// SPKIHash returns the base64 encoded SHA-256 hash of the
+// SubjectPublicKeyInfo of cert, as expected in PinnedSPKIHashes
+func SPKIHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Config builds the tls.Config described by the options
+func (o *TLSOptions) Config() (*tls.Config, error) {
+	config := &tls.Config{
+		Certificates:       o.ClientCertificates,
+		ServerName:         o.ServerName,
+		MinVersion:         o.MinVersion,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	if len(o.RootCAsPEM) > 0 {
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(o.RootCAsPEM) {
+			return nil, errors.New("failed to parse root CAs, no PEM certificate found")
+		}
+	}
+	if len(o.PinnedSPKIHashes) > 0 {
+		pins := make(map[string]bool)
+		for _, pin := range o.PinnedSPKIHashes {
+			pins[pin] = true
+		}
+		config.VerifyConnection = func(cs tls.ConnectionState) error {
+			// without verification nothing binds the other certificates
+			// sent by the server to its leaf, only the leaf can match
+			if len(cs.VerifiedChains) == 0 {
+				if len(cs.PeerCertificates) > 0 && pins[SPKIHash(cs.PeerCertificates[0])] {
+					return nil
+				}
+				return errors.New("server certificate does not match any pinned public key")
+			}
+			for _, chain := range cs.VerifiedChains {
+				for _, cert := range chain {
+					if pins[SPKIHash(cert)] {
+						return nil
+					}
+				}
+			}
+			return errors.New("server certificate does not match any pinned public key")
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,21 @@
+package helper
+
+import "context"
+
+// Tracer opens the spans describing the calls made to the REST API
+type Tracer interface {
+	// Start opens a span named name, child of the span carried by ctx if
+	// any, and returns a context carrying the new span
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is an operation being traced
+type Span interface {
+	// SetAttribute records a key value pair describing the operation
+	SetAttribute(key string, value interface{})
+	// TraceParent returns the W3C traceparent header identifying the span,
+	// it is sent with the HTTP requests made within the span
+	TraceParent() string
+	// End closes the span, err is the error the operation failed with
+	End(err error)
+}
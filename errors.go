@@ -0,0 +1,92 @@
+package wserest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError is returned when Wowza Streaming Engine answers with an HTTP error
+// status or with a body reporting "success": false
+type APIError struct {
+	StatusCode int
+	Success    bool
+	Message    string
+	Code       string
+	Method     string
+	URI        string
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URI, e.StatusCode, msg)
+}
+
+// wseStatus is the status part shared by WSE REST API responses
+type wseStatus struct {
+	Success *bool       `json:"success"`
+	Message string      `json:"message"`
+	Code    interface{} `json:"code"`
+}
+
+// checkResponse returns an *APIError when the status code or the body
+// indicates that the request failed
+func checkResponse(method string, uri string, statusCode int, body []byte) error {
+	var status wseStatus
+	decoded := json.Unmarshal(body, &status) == nil
+
+	failed := statusCode < 200 || statusCode > 299
+	if !failed && decoded && status.Success != nil && !*status.Success {
+		failed = true
+	}
+	if !failed {
+		return nil
+	}
+
+	e := &APIError{
+		StatusCode: statusCode,
+		Method:     method,
+		URI:        uri,
+		Body:       body,
+	}
+	if decoded {
+		e.Message = status.Message
+		if status.Success != nil {
+			e.Success = *status.Success
+		}
+		if status.Code != nil {
+			e.Code = fmt.Sprint(status.Code)
+		}
+	}
+	return e
+}
+
+// StatusCode returns the HTTP status code carried by err, or 0 when err is
+// not an *APIError
+func StatusCode(err error) int {
+	var e *APIError
+	if errors.As(err, &e) {
+		return e.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound reports whether err is an *APIError with status 404
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsConflict reports whether err is an *APIError with status 409
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+// IsUnauthorized reports whether err is an *APIError with status 401
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == http.StatusUnauthorized
+}
@@ -0,0 +1,58 @@
+package wserest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestAPIError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"message":"Application (missing) not found.","code":"404"}`))
+		case "/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/live":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"success":false,"message":"Application (live) already exists."}`))
+		case "/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/broken":
+			w.Write([]byte(`{"success":`))
+		default:
+			w.Write([]byte(`{"success":false,"message":"Internal error"}`))
+		}
+	}))
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+
+	_, err := NewApplication(settings, "missing", "", "", "", "").Get()
+	if !IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T", err)
+	}
+	if apiErr.Method != "GET" || apiErr.Code != "404" || apiErr.Message != "Application (missing) not found." {
+		t.Errorf("unexpected error fields: %+v", apiErr)
+	}
+
+	_, err = NewApplication(settings, "live", "", "", "", "").Create(nil, nil, nil, nil, nil, nil)
+	if !IsConflict(err) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+
+	_, err = NewApplication(settings, "other", "", "", "", "").Remove()
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusOK || apiErr.Success {
+		t.Fatalf("expected success:false to be reported, got %v", err)
+	}
+
+	_, err = NewApplication(settings, "broken", "", "", "", "").Get()
+	if err == nil || errors.As(err, &apiErr) {
+		t.Fatalf("expected decoding error, got %v", err)
+	}
+}
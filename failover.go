@@ -0,0 +1,203 @@
+package wserest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// attemptEndpoints sends the request once, to the active host first and
+// then to the other hosts of the settings while they are unreachable. With a
+// health check, the hosts marked down are tried last and probed in the
+// background.
+func (w *wowza) attemptEndpoints(ctx context.Context, req *helper.Request) (*helper.Response, time.Duration, error) {
+	hosts := w.settings.Hosts()
+	if len(hosts) < 2 || !strings.HasPrefix(req.URI, hosts[0]) {
+		return w.attempt(ctx, req)
+	}
+	path := strings.TrimPrefix(req.URI, hosts[0])
+	defer w.checkHealth(hosts)
+
+	active := w.settings.ActiveHost()
+	order := []string{active}
+	for _, host := range hosts {
+		if host != active {
+			order = append(order, host)
+		}
+	}
+	var up, down []string
+	for _, host := range order {
+		if w.health(host).isDown() {
+			down = append(down, host)
+		} else {
+			up = append(up, host)
+		}
+	}
+	order = append(up, down...)
+
+	var resp *helper.Response
+	var retryAfter time.Duration
+	var err error
+	for _, host := range order {
+		r := *req
+		r.URI = host + path
+		resp, retryAfter, err = w.attempt(ctx, &r)
+		if ctx.Err() == nil {
+			w.health(host).mark(!neverSent(err) && !isNetworkError(err))
+		}
+		if !isUnreachable(ctx, w.settings.RetryPolicy(), req.Method, err) {
+			if host != active {
+				w.settings.SetActiveHost(host)
+			}
+			return resp, retryAfter, err
+		}
+		if l := w.logger(); l != nil {
+			l.WarnContext(ctx, "wse host unreachable", "host", host, "error", err)
+		}
+	}
+	return resp, retryAfter, err
+}
+
+// hostHealth is what the health check knows of a host
+type hostHealth struct {
+	mu        sync.Mutex
+	down      bool
+	probing   bool
+	lastProbe time.Time
+}
+
+// health returns the health of host, nil when there is no health check
+func (w *wowza) health(host string) *hostHealth {
+	if w.settings.HealthCheck() == nil {
+		return nil
+	}
+	h := w.settings.HostState(helper.HealthCheckState, host, func() interface{} {
+		return &hostHealth{}
+	})
+	return h.(*hostHealth)
+}
+
+func (h *hostHealth) isDown() bool {
+	if h == nil {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.down
+}
+
+func (h *hostHealth) mark(up bool) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.down = !up
+}
+
+// startProbe reports whether a probe of the host is due, the caller must
+// then run it and call endProbe
+func (h *hostHealth) startProbe(interval time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.probing || time.Since(h.lastProbe) < interval {
+		return false
+	}
+	h.probing = true
+	h.lastProbe = time.Now()
+	return true
+}
+
+func (h *hostHealth) endProbe(up bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.probing = false
+	h.down = !up
+}
+
+// checkHealth starts the probes that are due: of the hosts marked down, and
+// of the first host while another one is active so that requests go back to
+// it once it has recovered
+func (w *wowza) checkHealth(hosts []string) {
+	config := w.settings.HealthCheck()
+	if config == nil {
+		return
+	}
+	active := w.settings.ActiveHost()
+	for i, host := range hosts {
+		h := w.health(host)
+		if (i == 0 && host != active || h.isDown()) && h.startProbe(config.Interval) {
+			go w.probe(host, h, config.Interval)
+		}
+	}
+}
+
+// probe sends a GET of the server to host and records whether it answered.
+// The first host becomes active again when it does.
+func (w *wowza) probe(host string, h *hostHealth, interval time.Duration) {
+	restURI := newURI(host).join("servers", w.serverInstance()).String()
+	body, _ := json.Marshal(requestProps(restURI))
+	req := &helper.Request{Method: GET.String(), URI: restURI, Header: make(http.Header), Body: body}
+
+	ctx := context.Background()
+	if interval > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, interval)
+		defer cancel()
+	}
+	resp, _, err := w.attempt(ctx, req)
+	up := err == nil && resp.StatusCode < http.StatusInternalServerError
+	h.endProbe(up)
+
+	hosts := w.settings.Hosts()
+	if !up || host != hosts[0] || w.settings.ActiveHost() == host {
+		return
+	}
+	w.settings.SetActiveHost(host)
+	if l := w.logger(); l != nil {
+		l.InfoContext(ctx, "wse host recovered", "host", host)
+	}
+}
+
+// HostsUp tells whether each host of settings is up according to its health
+// check, for health reporting. Hosts that were not contacted yet are left
+// out.
+func HostsUp(settings *helper.Settings) map[string]bool {
+	up := make(map[string]bool)
+	for host, h := range settings.HostStates(helper.HealthCheckState) {
+		up[host] = !h.(*hostHealth).isDown()
+	}
+	return up
+}
+
+// isUnreachable reports whether err shows that the host could not be
+// reached, so that another one may be tried. A request the retry policy does
+// not allow to send twice, such as a POST, only moves on when it cannot have
+// reached the server: a timeout or a dropped connection may come after the
+// server handled it.
+func isUnreachable(ctx context.Context, policy *helper.RetryPolicy, method string, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if neverSent(err) {
+		return true
+	}
+	return policy.Allows(method) && isNetworkError(err)
+}
+
+// neverSent reports whether err shows that the request was not sent, the
+// connection could not be made or the circuit breaker rejected it
+func neverSent(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,162 @@
+package wserest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// switchableServer answers while up and drops connections otherwise
+func switchableServer(up bool) (*httptest.Server, *int32, *int32) {
+	var calls, state int32
+	if up {
+		state = 1
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&state) == 0 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte(`{"path":"` + r.URL.Path + `"}`))
+	}))
+	return ts, &calls, &state
+}
+
+func TestFailover(t *testing.T) {
+	primary, primaryCalls, primaryUp := switchableServer(false)
+	defer primary.Close()
+	secondary, secondaryCalls, secondaryUp := switchableServer(true)
+	defer secondary.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHosts(primary.URL+"/v2", secondary.URL+"/v2")
+	settings.SetRetryPolicy(&helper.RetryPolicy{MaxAttempts: 2})
+	app := NewApplication(settings, "live", "", "", "", "")
+
+	response, err := app.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response["path"] != "/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/live" {
+		t.Fatalf("unexpected path %v", response["path"])
+	}
+	if atomic.LoadInt32(primaryCalls) != 1 || atomic.LoadInt32(secondaryCalls) != 1 || settings.ActiveHost() != secondary.URL+"/v2" {
+		t.Fatalf("expected failover to the secondary host, got %d and %d calls", atomic.LoadInt32(primaryCalls), atomic.LoadInt32(secondaryCalls))
+	}
+
+	atomic.StoreInt32(primaryUp, 1)
+	if _, err = NewRecording(settings, "live", "").Stop("cam"); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(primaryCalls) != 1 || atomic.LoadInt32(secondaryCalls) != 2 {
+		t.Fatalf("expected the secondary host to stay preferred, got %d and %d calls", atomic.LoadInt32(primaryCalls), atomic.LoadInt32(secondaryCalls))
+	}
+
+	atomic.StoreInt32(secondaryUp, 0)
+	if _, err = app.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if settings.ActiveHost() != primary.URL+"/v2" {
+		t.Fatalf("expected failback to the primary host, active is %s", settings.ActiveHost())
+	}
+
+	atomic.StoreInt32(primaryUp, 0)
+	if _, err = app.Get(); !isNetworkError(err) {
+		t.Fatalf("expected a network error when every host is down, got %v", err)
+	}
+}
+
+func TestFailoverPOST(t *testing.T) {
+	var primaryCalls int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryCalls, 1)
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer primary.Close()
+	secondary, secondaryCalls, _ := switchableServer(true)
+	defer secondary.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHosts(primary.URL+"/v2", secondary.URL+"/v2")
+	settings.SetTimeout(50 * time.Millisecond)
+
+	// the primary host may have handled the request before timing out
+	if _, err := NewPublisher(settings, "encoder").Create("secret"); err == nil {
+		t.Fatal("expected the response timeout to be returned")
+	}
+	if atomic.LoadInt32(&primaryCalls) != 1 || atomic.LoadInt32(secondaryCalls) != 0 {
+		t.Fatalf("expected a single call to the primary host, got %d and %d calls", atomic.LoadInt32(&primaryCalls), atomic.LoadInt32(secondaryCalls))
+	}
+
+	// no connection could be made, the request was never sent
+	primary.Close()
+	if _, err := NewPublisher(settings, "encoder").Create("secret"); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(secondaryCalls) != 1 || settings.ActiveHost() != secondary.URL+"/v2" {
+		t.Fatalf("expected failover to the secondary host, got %d calls", atomic.LoadInt32(secondaryCalls))
+	}
+}
+
+func TestFailoverHealthCheck(t *testing.T) {
+	primary, primaryCalls, primaryUp := switchableServer(false)
+	defer primary.Close()
+	secondary, _, _ := switchableServer(true)
+	defer secondary.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHosts(primary.URL+"/v2", secondary.URL+"/v2")
+	settings.SetHealthCheck(&helper.HealthCheck{Interval: 20 * time.Millisecond})
+	app := NewApplication(settings, "live", "", "", "", "")
+
+	if _, err := app.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if settings.ActiveHost() != secondary.URL+"/v2" {
+		t.Fatalf("expected failover to the secondary host, active is %s", settings.ActiveHost())
+	}
+	if up := HostsUp(settings); up[primary.URL+"/v2"] || !up[secondary.URL+"/v2"] {
+		t.Fatalf("expected the primary host to be down and the secondary one up, got %v", up)
+	}
+
+	// the probes of the primary host fail while it is down
+	time.Sleep(50 * time.Millisecond)
+	if _, err := app.Get(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if settings.ActiveHost() != secondary.URL+"/v2" {
+		t.Fatalf("expected the secondary host to stay active, active is %s", settings.ActiveHost())
+	}
+
+	atomic.StoreInt32(primaryUp, 1)
+	calls := atomic.LoadInt32(primaryCalls)
+	deadline := time.Now().Add(2 * time.Second)
+	for settings.ActiveHost() != primary.URL+"/v2" {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected failback to the primary host once it recovered, active is %s", settings.ActiveHost())
+		}
+		time.Sleep(25 * time.Millisecond)
+		if _, err := app.Get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if up := HostsUp(settings); !up[primary.URL+"/v2"] {
+		t.Fatalf("expected the primary host to be up, got %v", up)
+	}
+
+	// the probe is a GET of the server, then requests go to the primary host
+	probes := atomic.LoadInt32(primaryCalls) - calls
+	if _, err := app.Get(); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(primaryCalls) - calls; got != probes+1 {
+		t.Fatalf("expected the request to be sent to the primary host, got %d calls after %d probes", got, probes)
+	}
+}
@@ -0,0 +1,222 @@
+// Package httpauth parses the HTTP authentication headers following RFC 7235,
+// for the digest authentication of the client and of the wsetest server.
+package httpauth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Challenge is an RFC 7235 challenge, or the credentials of an Authorization
+// header: an auth-scheme followed by either a token68 or a list of
+// auth-params whose names are lower cased
+type Challenge struct {
+	Scheme  string
+	Token68 string
+	Params  map[string]string
+}
+
+// ParseChallenges parses a WWW-Authenticate (or Authorization) header value
+// following the RFC 7235 grammar
+//
+//	challenge  = auth-scheme [ 1*SP ( token68 / #auth-param ) ]
+//	auth-param = token BWS "=" BWS ( token / quoted-string )
+//
+// On a syntax error it returns the challenges parsed so far along with the
+// error
+func ParseChallenges(s string) ([]Challenge, error) {
+	p := authParser{s: s}
+	var challenges []Challenge
+	for {
+		p.skipSeparators()
+		if p.eof() {
+			return challenges, nil
+		}
+		c, err := p.challenge()
+		if err != nil {
+			return challenges, err
+		}
+		challenges = append(challenges, c)
+	}
+}
+
+// authParser is a tokenizer over a single header value
+type authParser struct {
+	s   string
+	pos int
+}
+
+func (p *authParser) eof() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *authParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+// skipSpaces skips optional white space and reports whether any was found
+func (p *authParser) skipSpaces() bool {
+	start := p.pos
+	for !p.eof() && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// skipSeparators skips the empty list elements allowed by the #rule
+func (p *authParser) skipSeparators() {
+	for p.skipSpaces() || p.peek() == ',' {
+		if p.peek() == ',' {
+			p.pos++
+		}
+	}
+}
+
+func (p *authParser) token() string {
+	start := p.pos
+	for !p.eof() && isTokenChar(p.s[p.pos]) {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+func (p *authParser) challenge() (Challenge, error) {
+	c := Challenge{Scheme: p.token(), Params: map[string]string{}}
+	if c.Scheme == "" {
+		return c, p.errorf("expected auth-scheme")
+	}
+	if !p.skipSpaces() || p.eof() || p.peek() == ',' {
+		if !p.eof() && p.peek() != ',' {
+			return c, p.errorf("expected space after auth-scheme")
+		}
+		return c, nil
+	}
+
+	if t := p.token68(); t != "" {
+		c.Token68 = t
+		return c, nil
+	}
+
+	for {
+		start := p.pos
+		name := p.token()
+		p.skipSpaces()
+		if name == "" || p.peek() != '=' {
+			// the next challenge starts here
+			p.pos = start
+			if len(c.Params) == 0 {
+				return c, p.errorf("expected auth-param")
+			}
+			return c, nil
+		}
+		p.pos++
+		p.skipSpaces()
+
+		value, err := p.value()
+		if err != nil {
+			return c, err
+		}
+		name = strings.ToLower(name)
+		if _, ok := c.Params[name]; ok {
+			return c, p.errorf("duplicate auth-param %q", name)
+		}
+		c.Params[name] = value
+
+		p.skipSpaces()
+		if p.eof() {
+			return c, nil
+		}
+		if p.peek() != ',' {
+			return c, p.errorf("expected comma")
+		}
+		p.skipSeparators()
+		if p.eof() {
+			return c, nil
+		}
+	}
+}
+
+// token68 consumes a token68 when it is the whole credentials of the
+// challenge, that is when it is followed by a comma or the end of the value
+func (p *authParser) token68() string {
+	start := p.pos
+	for !p.eof() && isToken68Char(p.s[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return ""
+	}
+	for !p.eof() && p.s[p.pos] == '=' {
+		p.pos++
+	}
+	end := p.pos
+	p.skipSpaces()
+	if p.eof() || p.peek() == ',' {
+		return p.s[start:end]
+	}
+	p.pos = start
+	return ""
+}
+
+func (p *authParser) value() (string, error) {
+	if p.peek() != '"' {
+		if v := p.token(); v != "" {
+			return v, nil
+		}
+		return "", p.errorf("expected token or quoted-string")
+	}
+
+	var b strings.Builder
+	for p.pos++; !p.eof(); p.pos++ {
+		switch c := p.s[p.pos]; c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if p.pos++; p.eof() {
+				return "", p.errorf("unterminated quoted-pair")
+			}
+			b.WriteByte(p.s[p.pos])
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated quoted-string")
+}
+
+func (p *authParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("failed to parse auth header at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func isTokenChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+func isToken68Char(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("-._~+/", c) >= 0
+}
+
+// QuoteString formats s as an RFC 7230 quoted-string
+func QuoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package httpauth
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseChallengesSyntax(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []Challenge
+		err    bool
+	}{
+		{
+			header: `Digest realm="a \"quoted\" realm", nonce="x\\y"`,
+			want:   []Challenge{{Scheme: "Digest", Params: map[string]string{"realm": `a "quoted" realm`, "nonce": `x\y`}}},
+		},
+		{
+			header: `Newauth realm="apps", type=1, title="Login to \"apps\"", Basic realm="simple"`,
+			want: []Challenge{
+				{Scheme: "Newauth", Params: map[string]string{"realm": "apps", "type": "1", "title": `Login to "apps"`}},
+				{Scheme: "Basic", Params: map[string]string{"realm": "simple"}},
+			},
+		},
+		{
+			header: `Bearer mF_9.B5f-4.1JqM==, Digest REALM = "r" ,, NONCE=n`,
+			want: []Challenge{
+				{Scheme: "Bearer", Token68: "mF_9.B5f-4.1JqM==", Params: map[string]string{}},
+				{Scheme: "Digest", Params: map[string]string{"realm": "r", "nonce": "n"}},
+			},
+		},
+		{
+			header: `, Negotiate ,Basic`,
+			want: []Challenge{
+				{Scheme: "Negotiate", Params: map[string]string{}},
+				{Scheme: "Basic", Params: map[string]string{}},
+			},
+		},
+		{header: `Digest realm="unterminated`, err: true},
+		{header: `Digest realm="a", realm="b"`, err: true},
+		{header: `Digest nonce="n", realm=`, err: true},
+		{header: `Basic realm="a", "x"`, want: []Challenge{{Scheme: "Basic", Params: map[string]string{"realm": "a"}}}, err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseChallenges(tt.header)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: got error %v", tt.header, err)
+		}
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.header, got, tt.want)
+		}
+	}
+}
+
+// formatChallenge writes c back with every auth-param value quoted
+func formatChallenge(c Challenge) string {
+	if c.Token68 != "" {
+		return c.Scheme + " " + c.Token68
+	}
+	var names []string
+	for name := range c.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var params []string
+	for _, name := range names {
+		params = append(params, name+"="+QuoteString(c.Params[name]))
+	}
+	if len(params) == 0 {
+		return c.Scheme
+	}
+	return c.Scheme + " " + strings.Join(params, ", ")
+}
+
+func FuzzParseChallenges(f *testing.F) {
+	f.Add(`Basic realm="a, b", Digest realm="Wowza", qop="auth,auth-int", nonce="n", Bearer`)
+	f.Add(`Digest realm="a \"quoted\" realm", nonce="x\\y", algorithm=SHA-256, userhash=true`)
+	f.Add(`Bearer mF_9.B5f-4.1JqM==, Negotiate`)
+	f.Add(`Digest realm="unterminated`)
+	f.Add(`,,, Digest  realm = "r" ,`)
+
+	f.Fuzz(func(t *testing.T, header string) {
+		challenges, err := ParseChallenges(header)
+		if err != nil {
+			return
+		}
+
+		var formatted []string
+		for _, c := range challenges {
+			formatted = append(formatted, formatChallenge(c))
+		}
+		again, err := ParseChallenges(strings.Join(formatted, ", "))
+		if err != nil {
+			t.Fatalf("failed to parse formatted %q: %v", formatted, err)
+		}
+		if !reflect.DeepEqual(challenges, again) {
+			t.Fatalf("round trip of %q: got %+v, want %+v", header, again, challenges)
+		}
+	})
+}
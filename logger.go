@@ -0,0 +1,19 @@
+package wserest
+
+import (
+	"log/slog"
+	"os"
+)
+
+var debugLogger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+// logger returns the logger of the settings, nil when nothing is logged
+func (w *wowza) logger() *slog.Logger {
+	if l := w.settings.Logger(); l != nil {
+		return l
+	}
+	if w.settings.IsDebug() {
+		return debugLogger
+	}
+	return nil
+}
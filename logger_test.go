@@ -0,0 +1,53 @@
+package wserest
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application"
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+	"github.com/sebastien4/wse-rest-library-go/redact"
+)
+
+func TestLoggerRedaction(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/publishers") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"success":true,"drmConfig":{"ezDRMPassword":"s3cr3t-drm"}}`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetAuthenticator(NewBasicAuthenticator("admin", "s3cr3t-admin"))
+	settings.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	drm := application.NewDrmConfig()
+	drm.EzDRMPassword = "s3cr3t-drm"
+	if _, err := NewApplication(settings, "live", "", "", "", "").Create(nil, nil, nil, nil, nil, drm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewUser(settings, "bob").Create("s3cr3t-user", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewPublisher(settings, "camera").Create("s3cr3t-publisher"); !IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "s3cr3t") {
+		t.Errorf("secrets leaked in log:\n%s", out)
+	}
+	for _, want := range []string{`"verb":"POST"`, `"status":404`, `"level":"WARN"`, `"duration":`, redact.Redacted} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected log to contain %s:\n%s", want, out)
+		}
+	}
+}
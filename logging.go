@@ -1,6 +1,7 @@
 package wserest
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
@@ -16,27 +17,42 @@ type Logging struct {
 func NewLogging(settings *helper.Settings) *Logging {
 	l := new(Logging)
 	l.init(settings)
-	l.baseURI = l.host() + "/servers/" + l.serverInstance() + "/logfiles"
+	l.baseURI = l.serverURI().join("logfiles").String()
 	return l
 }
 
 // GetNewestFirst retrieves the list of server log files
 func (l *Logging) GetNewestFirst() (map[string]interface{}, error) {
-	l.setRestURI(l.baseURI + "?order=newestFirst")
+	return l.GetNewestFirstWithContext(context.Background())
+}
+
+// GetNewestFirstWithContext is like GetNewestFirst but honors ctx for cancellation and deadlines
+func (l *Logging) GetNewestFirstWithContext(ctx context.Context) (map[string]interface{}, error) {
+	restURI := l.subURI().with("order", "newestFirst").String()
 
-	return l.sendRequest(l.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return l.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // GetLineCount retrieves the contents of a Server Log
 func (l *Logging) GetLineCount(num int) (map[string]interface{}, error) {
-	l.setRestURI(l.baseURI + "/wowzastreamingengine_access.log?lineCount=" + strconv.Itoa(num))
+	return l.GetLineCountWithContext(context.Background(), num)
+}
 
-	return l.sendRequest(l.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+// GetLineCountWithContext is like GetLineCount but honors ctx for cancellation and deadlines
+func (l *Logging) GetLineCountWithContext(ctx context.Context, num int) (map[string]interface{}, error) {
+	restURI := l.subURI("wowzastreamingengine_access.log").with("lineCount", strconv.Itoa(num)).String()
+
+	return l.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // Search retrieves the contents of a Server Log containing str
 func (l *Logging) Search(str string) (map[string]interface{}, error) {
-	l.setRestURI(l.baseURI + "/wowzastreamingengine_access.log?search=" + str)
+	return l.SearchWithContext(context.Background(), str)
+}
+
+// SearchWithContext is like Search but honors ctx for cancellation and deadlines
+func (l *Logging) SearchWithContext(ctx context.Context, str string) (map[string]interface{}, error) {
+	restURI := l.subURI("wowzastreamingengine_access.log").with("search", str).String()
 
-	return l.sendRequest(l.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return l.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
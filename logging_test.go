@@ -16,9 +16,7 @@ func TestLogging(t *testing.T) {
 
 	sf := NewLogging(settings)
 
-	if UseWowza == "" {
-		return
-	}
+	useCassette(t, settings, "logging")
 
 	response, err := sf.Search("MediaCasterStreamValidator.init")
 	if err != nil {
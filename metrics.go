@@ -0,0 +1,242 @@
+package wserest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the latency histogram
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// resourceSegment describes a path segment naming a resource, collections
+// are followed by the name of an item
+type resourceSegment struct {
+	label      string
+	collection bool
+}
+
+var resourceSegments = map[string]resourceSegment{
+	"servers":             {"servers", true},
+	"vhosts":              {"vhosts", true},
+	"applications":        {"applications", true},
+	"instances":           {"instances", true},
+	"incomingstreams":     {"incomingstreams", true},
+	"streamfiles":         {"streamfiles", true},
+	"streamrecorders":     {"streamrecorders", true},
+	"dvrstores":           {"dvrstores", true},
+	"pushpublish":         {"pushpublish", false},
+	"mapentries":          {"pushpublish", true},
+	"smilfiles":           {"smilfiles", true},
+	"users":               {"users", true},
+	"publishers":          {"publishers", true},
+	"logfiles":            {"logfiles", true},
+	"machine":             {"machine", false},
+	"monitoring":          {"monitoring", false},
+	"adv":                 {"adv", false},
+	"drm":                 {"drm", false},
+	"dvr":                 {"dvr", false},
+	"security":            {"security", false},
+	"streamconfiguration": {"streamconfiguration", false},
+	"transcoder":          {"transcoder", false},
+}
+
+// resourceOf returns the kind of resource targeted by uri, such as
+// "applications" or "streamrecorders"
+func resourceOf(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "other"
+	}
+	resource := "other"
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i < len(segments); i++ {
+		if s, ok := resourceSegments[segments[i]]; ok {
+			resource = s.label
+			if s.collection {
+				i++
+			}
+		}
+	}
+	return resource
+}
+
+// errorClass classifies the error of a call for the errors counter
+func errorClass(err error) string {
+	var apiErr *APIError
+	var netErr net.Error
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &apiErr):
+		switch {
+		case apiErr.StatusCode >= 500:
+			return "server"
+		case apiErr.StatusCode >= 400:
+			return "client"
+		}
+		return "api"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return "decode"
+	}
+	return "other"
+}
+
+type metricKey struct {
+	resource string
+	verb     string
+	label    string
+}
+
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// Metrics records the calls made to the REST API and exposes them in the
+// Prometheus text format. Register its Middleware on the settings and serve
+// it on the metrics endpoint:
+//
+//	metrics := wserest.NewMetrics()
+//	settings.Use(metrics.Middleware())
+//	http.Handle("/metrics", metrics)
+type Metrics struct {
+	mu        sync.Mutex
+	requests  map[metricKey]uint64
+	errors    map[metricKey]uint64
+	durations map[metricKey]*histogram
+}
+
+// NewMetrics create Metrics object
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:  make(map[metricKey]uint64),
+		errors:    make(map[metricKey]uint64),
+		durations: make(map[metricKey]*histogram),
+	}
+}
+
+// Middleware returns the middleware recording each call
+func (m *Metrics) Middleware() helper.Middleware {
+	return func(next helper.Handler) helper.Handler {
+		return func(ctx context.Context, req *helper.Request) (*helper.Response, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			m.observe(req, resp, time.Since(start), err)
+			return resp, err
+		}
+	}
+}
+
+func (m *Metrics) observe(req *helper.Request, resp *helper.Response, duration time.Duration, err error) {
+	resource := resourceOf(req.URI)
+	code := "none"
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[metricKey{resource, req.Method, code}]++
+	if err != nil {
+		m.errors[metricKey{resource, req.Method, errorClass(err)}]++
+	}
+
+	key := metricKey{resource: resource, verb: req.Method}
+	h, ok := m.durations[key]
+	if !ok {
+		h = &histogram{buckets: make([]uint64, len(durationBuckets))}
+		m.durations[key] = h
+	}
+	seconds := duration.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+
+	b.WriteString("# HELP wse_client_requests_total Calls made to the Wowza Streaming Engine REST API.\n")
+	b.WriteString("# TYPE wse_client_requests_total counter\n")
+	for _, k := range sortedKeys(m.requests) {
+		fmt.Fprintf(&b, "wse_client_requests_total{resource=%q,verb=%q,code=%q} %d\n", k.resource, k.verb, k.label, m.requests[k])
+	}
+
+	b.WriteString("# HELP wse_client_errors_total Failed calls to the Wowza Streaming Engine REST API by error class.\n")
+	b.WriteString("# TYPE wse_client_errors_total counter\n")
+	for _, k := range sortedKeys(m.errors) {
+		fmt.Fprintf(&b, "wse_client_errors_total{resource=%q,verb=%q,class=%q} %d\n", k.resource, k.verb, k.label, m.errors[k])
+	}
+
+	b.WriteString("# HELP wse_client_request_duration_seconds Duration of the calls to the Wowza Streaming Engine REST API, retries included.\n")
+	b.WriteString("# TYPE wse_client_request_duration_seconds histogram\n")
+	for _, k := range sortedKeys(m.durations) {
+		h := m.durations[k]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(&b, "wse_client_request_duration_seconds_bucket{resource=%q,verb=%q,le=%q} %d\n", k.resource, k.verb, strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+		}
+		fmt.Fprintf(&b, "wse_client_request_duration_seconds_bucket{resource=%q,verb=%q,le=\"+Inf\"} %d\n", k.resource, k.verb, h.count)
+		fmt.Fprintf(&b, "wse_client_request_duration_seconds_sum{resource=%q,verb=%q} %s\n", k.resource, k.verb, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "wse_client_request_duration_seconds_count{resource=%q,verb=%q} %d\n", k.resource, k.verb, h.count)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func sortedKeys[V any](m map[metricKey]V) []metricKey {
+	keys := make([]metricKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].resource != keys[j].resource {
+			return keys[i].resource < keys[j].resource
+		}
+		if keys[i].verb != keys[j].verb {
+			return keys[i].verb < keys[j].verb
+		}
+		return keys[i].label < keys[j].label
+	})
+	return keys
+}
@@ -0,0 +1,70 @@
+package wserest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestResourceOf(t *testing.T) {
+	base := "http://localhost:8087/v2/servers/_defaultServer_/vhosts/_defaultVHost_"
+	for uri, want := range map[string]string{
+		base + "/applications/live":  "applications",
+		base + "/applications/users": "applications",
+		base + "/applications/live/instances/_definst_/streamrecorders/cam/actions/stopRecording": "streamrecorders",
+		base + "/applications/live/instances/_definst_/dvrstores?name=x":                          "dvrstores",
+		base + "/applications/live/pushpublish/mapentries/target":                                 "pushpublish",
+		base + "/applications/live/monitoring/current":                                            "monitoring",
+		"http://localhost:8087/v2/machine/monitoring/current":                                     "monitoring",
+		"http://localhost:8087/v2/servers/_defaultServer_/users/bob":                              "users",
+		"http://localhost:8087/v2/unknown":                                                        "other",
+	} {
+		if got := resourceOf(uri); got != want {
+			t.Errorf("resourceOf(%s) = %s, expected %s", uri, got, want)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	metrics := NewMetrics()
+	settings.Use(metrics.Middleware())
+
+	NewApplication(settings, "live", "", "", "", "").Get()
+	NewApplication(settings, "live", "", "", "", "").Get()
+	NewApplication(settings, "missing", "", "", "", "").Remove()
+	NewRecording(settings, "live", "").Stop("cam")
+
+	rec := httptest.NewRecorder()
+	metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	out := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE wse_client_requests_total counter\n",
+		`wse_client_requests_total{resource="applications",verb="GET",code="200"} 2` + "\n",
+		`wse_client_requests_total{resource="applications",verb="DELETE",code="404"} 1` + "\n",
+		`wse_client_requests_total{resource="streamrecorders",verb="PUT",code="200"} 1` + "\n",
+		`wse_client_errors_total{resource="applications",verb="DELETE",class="client"} 1` + "\n",
+		`wse_client_request_duration_seconds_bucket{resource="applications",verb="GET",le="+Inf"} 2` + "\n",
+		`wse_client_request_duration_seconds_count{resource="streamrecorders",verb="PUT"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected metrics to contain %s got:\n%s", want, out)
+		}
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %s", ct)
+	}
+}
@@ -0,0 +1,88 @@
+package wserest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestMiddleware(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Request-Id") != "42" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"message":"not found"}`))
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]interface{}{"tagged": body["tagged"]})
+	}))
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+
+	var order []string
+	var calls []string
+	var statuses []int
+	var payloads []interface{}
+	settings.Use(
+		func(next helper.Handler) helper.Handler {
+			return func(ctx context.Context, req *helper.Request) (*helper.Response, error) {
+				order = append(order, "outer")
+				req.Header.Set("X-Request-Id", "42")
+				resp, err := next(ctx, req)
+				calls = append(calls, req.Method+" "+req.URI)
+				if resp != nil {
+					statuses = append(statuses, resp.StatusCode)
+					payloads = append(payloads, resp.Payload)
+				}
+				return resp, err
+			}
+		},
+		func(next helper.Handler) helper.Handler {
+			return func(ctx context.Context, req *helper.Request) (*helper.Response, error) {
+				order = append(order, "inner")
+				var body map[string]interface{}
+				json.Unmarshal(req.Body, &body)
+				body["tagged"] = true
+				req.Body, _ = json.Marshal(body)
+				return next(ctx, req)
+			}
+		},
+	)
+
+	response, err := NewApplication(settings, "live", "", "", "", "").Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response["tagged"] != true {
+		t.Errorf("expected the modified body to be sent, got %v", response)
+	}
+	if _, err = NewPublisher(settings, "missing").Remove(); !IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+
+	if strings.Join(order, ",") != "outer,inner,outer,inner" {
+		t.Errorf("unexpected middleware order %v", order)
+	}
+	appURI := ts.URL + "/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/live"
+	if len(calls) != 2 || calls[0] != "GET "+appURI || !strings.HasPrefix(calls[1], "DELETE ") {
+		t.Errorf("unexpected calls %v", calls)
+	}
+	if len(statuses) != 2 || statuses[0] != http.StatusOK || statuses[1] != http.StatusNotFound {
+		t.Errorf("unexpected statuses %v", statuses)
+	}
+	if payloads[0] == nil || payloads[1] != nil {
+		t.Errorf("expected the decoded payload of successful calls only, got %v", payloads)
+	}
+}
@@ -0,0 +1,280 @@
+package wserest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// ErrQueued is returned, joined with the error of the call, when a failed
+// mutating call was recorded by an OfflineQueue to be replayed later
+var ErrQueued = errors.New("request queued for replay")
+
+// QueueEntry is a mutating request recorded by an OfflineQueue
+type QueueEntry struct {
+	ID     int64       `json:"id"`
+	Time   time.Time   `json:"time"`
+	Method string      `json:"method"`
+	URI    string      `json:"uri"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body"`
+	// Err is the error of the replay that failed permanently
+	Err error `json:"-"`
+}
+
+type replayKey struct{}
+
+// OfflineQueue records the POST, PUT and DELETE requests failing because the
+// server is unreachable in a file, one JSON entry per line, so that they can
+// be replayed in order once it is back, even after a restart. The file holds
+// the request bodies, passwords included, and is only readable by its owner:
+//
+//	queue, err := wserest.NewOfflineQueue("/var/lib/app/wse-queue.jsonl")
+//	settings.Use(queue.Middleware())
+//	...
+//	failed, err := queue.Replay(ctx, settings)
+type OfflineQueue struct {
+	// replaying serializes the replays
+	replaying sync.Mutex
+
+	mu      sync.Mutex
+	path    string
+	entries []QueueEntry
+	nextID  int64
+}
+
+// NewOfflineQueue create OfflineQueue object persisted to path, loading the
+// entries it already holds. It fails when one of them cannot be decoded, the
+// file must then be repaired by hand.
+func NewOfflineQueue(path string) (*OfflineQueue, error) {
+	q := &OfflineQueue{path: path, nextID: 1}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open offline queue: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var size int64
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				// a partially written last line is left by a crash while
+				// appending, the entry was never acknowledged
+				if err = f.Truncate(size); err != nil {
+					return nil, fmt.Errorf("failed to repair offline queue: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read offline queue: %w", err)
+		}
+		size += int64(len(line))
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var e QueueEntry
+		if err = json.Unmarshal(line, &e); err != nil {
+			// the entry was acknowledged, dropping it would lose a request
+			return nil, fmt.Errorf("failed to decode line %d of offline queue %s: %w", n, path, err)
+		}
+		q.entries = append(q.entries, e)
+		if e.ID >= q.nextID {
+			q.nextID = e.ID + 1
+		}
+	}
+	return q, nil
+}
+
+// Entries returns the requests waiting to be replayed, oldest first
+func (q *OfflineQueue) Entries() []QueueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]QueueEntry(nil), q.entries...)
+}
+
+// Middleware returns the middleware recording the failed mutating requests
+func (q *OfflineQueue) Middleware() helper.Middleware {
+	return func(next helper.Handler) helper.Handler {
+		return func(ctx context.Context, req *helper.Request) (*helper.Response, error) {
+			resp, err := next(ctx, req)
+			if req.Method == GET.String() || ctx.Value(replayKey{}) != nil || !isOffline(ctx, err) {
+				return resp, err
+			}
+			if qerr := q.push(req); qerr != nil {
+				return resp, fmt.Errorf("%w (%v)", err, qerr)
+			}
+			return resp, fmt.Errorf("%w: %w", ErrQueued, err)
+		}
+	}
+}
+
+// isOffline reports whether err shows that the server could not process
+// the request for now
+func isOffline(ctx context.Context, err error) bool {
+	switch StatusCode(err) {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	return isNetworkError(err) || errors.Is(err, ErrCircuitOpen)
+}
+
+// push appends the entry to the file before acknowledging it
+func (q *OfflineQueue) push(req *helper.Request) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e := QueueEntry{
+		ID:     q.nextID,
+		Time:   time.Now(),
+		Method: req.Method,
+		URI:    req.URI,
+		Header: req.Header,
+		Body:   req.Body,
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(q.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open offline queue: %w", err)
+	}
+	info, err := f.Stat()
+	if err == nil {
+		if _, err = f.Write(append(line, '\n')); err == nil {
+			err = f.Sync()
+		}
+		if err != nil {
+			// drop what was written of the line, the next one would be
+			// appended to it
+			f.Truncate(info.Size())
+		}
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write offline queue: %w", err)
+	}
+
+	q.nextID++
+	q.entries = append(q.entries, e)
+	return nil
+}
+
+// Replay sends the queued requests in order with settings. It stops at the
+// first one failing because the server is still unreachable, leaving it and
+// the following ones queued. The requests failing for another reason are
+// removed from the queue and returned with their error.
+func (q *OfflineQueue) Replay(ctx context.Context, settings *helper.Settings) ([]QueueEntry, error) {
+	q.replaying.Lock()
+	defer q.replaying.Unlock()
+
+	// the requests are sent without holding mu, new entries may be pushed
+	// meanwhile, only Replay removes them
+	entries := q.Entries()
+	w := &wowza{settings: settings}
+	ctx = context.WithValue(ctx, replayKey{}, true)
+
+	var failed []QueueEntry
+	var err error
+	replayed := 0
+	for _, e := range entries {
+		req := &helper.Request{Method: e.Method, URI: e.URI, Header: e.Header.Clone(), Body: e.Body}
+		if req.Header == nil {
+			req.Header = make(http.Header)
+		}
+		contents := make(map[string]interface{})
+		_, err = w.call(ctx, req, &contents)
+		if err != nil && (ctx.Err() != nil || isOffline(ctx, err)) {
+			break
+		}
+		if err != nil {
+			e.Err = err
+			failed = append(failed, e)
+		}
+		err = nil
+		replayed++
+	}
+
+	if replayed > 0 {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if werr := q.rewrite(q.entries[replayed:]); werr != nil {
+			return failed, werr
+		}
+		q.entries = q.entries[replayed:]
+	}
+	return failed, err
+}
+
+// Run replays the queue every interval until ctx is done, report is called
+// with the requests failing permanently
+func (q *OfflineQueue) Run(ctx context.Context, settings *helper.Settings, interval time.Duration, report func([]QueueEntry)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if failed, _ := q.Replay(ctx, settings); len(failed) > 0 && report != nil {
+			report(failed)
+		}
+	}
+}
+
+// rewrite replaces the file with entries, atomically
+func (q *OfflineQueue) rewrite(entries []QueueEntry) error {
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write offline queue: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	buf := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(buf)
+	for _, e := range entries {
+		if err = enc.Encode(e); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), q.path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write offline queue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package wserest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestOfflineQueue(t *testing.T) {
+	var up int32
+	var mu sync.Mutex
+	var received []string
+	var current atomic.Pointer[OfflineQueue]
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&up) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mu.Lock()
+		received = append(received, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Method == http.MethodPost {
+			// the queue stays usable while it is replayed
+			done := make(chan struct{})
+			go func() {
+				current.Load().Entries()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Error("the queue is locked during the replay")
+			}
+		}
+		if strings.HasSuffix(r.URL.Path, "/gone") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+	queue, err := NewOfflineQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.Store(queue)
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetRetryPolicy(nil)
+	settings.Use(queue.Middleware())
+
+	if _, err = NewPublisher(settings, "camera").Create("secret"); !errors.Is(err, ErrQueued) || StatusCode(err) != http.StatusServiceUnavailable {
+		t.Fatalf("expected the create to be queued, got %v", err)
+	}
+	if _, err = NewApplication(settings, "gone", "", "", "", "").Remove(); !errors.Is(err, ErrQueued) {
+		t.Fatalf("expected the delete to be queued, got %v", err)
+	}
+	if _, err = NewRecording(settings, "live", "").Stop("cam"); !errors.Is(err, ErrQueued) {
+		t.Fatalf("expected the action to be queued, got %v", err)
+	}
+	if _, err = NewApplication(settings, "live", "", "", "", "").Get(); errors.Is(err, ErrQueued) {
+		t.Fatal("expected GET not to be queued")
+	}
+
+	if failed, err := queue.Replay(context.Background(), settings); err == nil || len(failed) != 0 || len(queue.Entries()) != 3 {
+		t.Fatalf("expected the replay to stop while offline, got %v, %v", failed, err)
+	}
+
+	// a crash while appending leaves a partial line behind
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString(`{"id":4,"meth`)
+	f.Close()
+	queue, err = NewOfflineQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.Store(queue)
+	if len(queue.Entries()) != 3 {
+		t.Fatalf("expected 3 entries to be reloaded, got %d", len(queue.Entries()))
+	}
+	if b, _ := os.ReadFile(path); b[len(b)-1] != '\n' {
+		t.Fatalf("expected the partial line to be truncated, got %s", b)
+	}
+
+	atomic.StoreInt32(&up, 1)
+	failed, err := queue.Replay(context.Background(), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || !IsNotFound(failed[0].Err) || failed[0].Method != "DELETE" {
+		t.Fatalf("expected the delete to fail permanently, got %+v", failed)
+	}
+	expected := []string{
+		"POST /v2/servers/_defaultServer_/publishers",
+		"DELETE /v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/gone",
+		"PUT /v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/live/instances/_definst_/streamrecorders/cam/actions/stopRecording",
+	}
+	if strings.Join(received, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("expected replay in order, got %v", received)
+	}
+	if len(queue.Entries()) != 0 {
+		t.Fatalf("expected the queue to be empty, got %v", queue.Entries())
+	}
+	if b, _ := os.ReadFile(path); len(b) != 0 {
+		t.Fatalf("expected the queue file to be empty, got %s", b)
+	}
+}
+
+func TestOfflineQueueCorruptLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.jsonl")
+	content := `{"id":1,"method":"DELETE","uri":"http://localhost:8087/v2/servers/_defaultServer_/publishers/a"}` + "\n" +
+		`{"id":2,"method":"DELETE",,}` + "\n" +
+		`{"id":3,"method":"DELETE","uri":"http://localhost:8087/v2/servers/_defaultServer_/publishers/b"}` + "\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewOfflineQueue(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected the corrupt line to be reported, got %v", err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != content {
+		t.Fatalf("expected the queue file to be left untouched, got %s", b)
+	}
+}
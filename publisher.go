@@ -1,6 +1,7 @@
 package wserest
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
@@ -10,41 +11,53 @@ import (
 // Publisher is publisher utility
 type Publisher struct {
 	wowza
+	name string
 }
 
 // NewPublisher creates Publisher object
 func NewPublisher(settings *helper.Settings, publisherName string) *Publisher {
 	p := new(Publisher)
 	p.init(settings)
-	p.props["name"] = publisherName
-	p.props["password"] = ""
-	p.baseURI = p.host() + "/servers/" + p.serverInstance() + "/publishers"
+	p.name = publisherName
+	p.baseURI = p.serverURI().join("publishers").String()
 	return p
 }
 
 // Create adds a new Publisher to the list
 func (p *Publisher) Create(password string) (map[string]interface{}, error) {
-	p.props["password"] = password
-	p.setRestURI(p.baseURI)
-	response, err := p.sendRequest(p.preparePropertiesForRequest(), []base.Entity{}, POST, "")
+	return p.CreateWithContext(context.Background(), password)
+}
+
+// CreateWithContext is like Create but honors ctx for cancellation and deadlines
+func (p *Publisher) CreateWithContext(ctx context.Context, password string) (map[string]interface{}, error) {
+	props := requestProps(p.baseURI)
+	props["name"] = p.name
+	props["password"] = password
+	response, err := p.sendRequest(ctx, props, []base.Entity{}, POST, "")
 
 	return response, err
 }
 
 // GetAll retrieves the list of server Publishers
 func (p *Publisher) GetAll() (map[string]interface{}, error) {
-	p.AddSkipParameter("name")
-	p.AddSkipParameter("password")
+	return p.GetAllWithContext(context.Background())
+}
 
-	p.setRestURI(p.baseURI)
-	return p.sendRequest(p.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+// GetAllWithContext is like GetAll but honors ctx for cancellation and deadlines
+func (p *Publisher) GetAllWithContext(ctx context.Context) (map[string]interface{}, error) {
+	return p.sendRequest(ctx, requestProps(p.baseURI), []base.Entity{}, GET, "")
 }
 
 // Remove deletes the specified Publisher configuration
 func (p *Publisher) Remove() (map[string]interface{}, error) {
-	p.setRestURI(p.baseURI + "/" + p.props["name"].(string))
+	return p.RemoveWithContext(context.Background())
+}
+
+// RemoveWithContext is like Remove but honors ctx for cancellation and deadlines
+func (p *Publisher) RemoveWithContext(ctx context.Context) (map[string]interface{}, error) {
+	restURI := p.subURI(p.name).String()
 
-	return p.sendRequest(p.preparePropertiesForRequest(), []base.Entity{}, DELETE, "")
+	return p.sendRequest(ctx, requestProps(restURI), []base.Entity{}, DELETE, "")
 }
 
 func (p *Publisher) getAdvancedSettings(urlProps map[string]interface{}) []*helper.AdvancedSettingItem {
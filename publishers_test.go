@@ -16,9 +16,7 @@ func TestPublishers(t *testing.T) {
 
 	sf := NewPublisher(settings, "myUser")
 
-	if UseWowza == "" {
-		return
-	}
+	useCassette(t, settings, "publishers")
 
 	response, err := sf.Create("myPass")
 	if err != nil {
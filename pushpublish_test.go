@@ -16,9 +16,7 @@ func TestPushPublish(t *testing.T) {
 
 	sf := NewStreamTarget(settings, "live")
 
-	if UseWowza == "" {
-		return
-	}
+	useCassette(t, settings, "pushpublish")
 
 	response, err := sf.Create("myStream", "ppsource", "rtmp", "locahost", "", "", "myStream", "")
 	if err != nil {
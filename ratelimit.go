@@ -0,0 +1,102 @@
+package wserest
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// hostLimiter enforces a helper.RateLimit for one host
+type hostLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	last     time.Time
+	inFlight chan struct{}
+}
+
+func newHostLimiter(config helper.RateLimit) *hostLimiter {
+	l := &hostLimiter{rate: config.RequestsPerSecond, burst: float64(config.Burst), last: time.Now()}
+	if l.burst < 1 {
+		l.burst = 1
+	}
+	l.tokens = l.burst
+	if config.MaxInFlight > 0 {
+		l.inFlight = make(chan struct{}, config.MaxInFlight)
+	}
+	return l
+}
+
+// limiter returns the limiter of the host of uri, nil when there is no
+// rate limit
+func (w *wowza) limiter(uri string) *hostLimiter {
+	config := w.settings.RateLimit()
+	if config == nil {
+		return nil
+	}
+	l := w.settings.HostState(helper.RateLimitState, hostOf(uri), func() interface{} {
+		return newHostLimiter(*config)
+	})
+	return l.(*hostLimiter)
+}
+
+// hostOf returns the scheme and host of uri
+func hostOf(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// wait blocks until a request may be sent according to the rate
+func (l *hostLimiter) wait(ctx context.Context) error {
+	if l == nil || l.rate <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	// the token is taken right away, waiting for the debt to be paid back
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// acquire blocks until fewer than MaxInFlight requests are pending, the
+// returned function releases the slot
+func (l *hostLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil || l.inFlight == nil {
+		return func() {}, nil
+	}
+	select {
+	case l.inFlight <- struct{}{}:
+		return func() { <-l.inFlight }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,117 @@
+package wserest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestMaxInFlight(t *testing.T) {
+	var inFlight, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetRateLimit(&helper.RateLimit{MaxInFlight: 2})
+
+	stats := NewStatistics(settings)
+	app := NewApplication(settings, "live", "", "", "", "")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			stats.GetIncomingApplicationStatistics(app, "camera", "")
+		}()
+		go func() {
+			defer wg.Done()
+			app.Get()
+		}()
+	}
+	wg.Wait()
+	if peak != 2 {
+		t.Fatalf("expected at most 2 requests in flight, got %d", peak)
+	}
+}
+
+func TestRequestsPerSecond(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetRateLimit(&helper.RateLimit{RequestsPerSecond: 50, Burst: 2})
+	app := NewApplication(settings, "live", "", "", "", "")
+
+	start := time.Now()
+	for i := 0; i < 7; i++ {
+		if _, err := app.Get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("expected 5 requests above the burst to take 100ms, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	app.Get()
+	if _, err := app.GetWithContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected waiting to be canceled, got %v", err)
+	}
+}
+
+func TestRateLimitWaitsOutsideSlot(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetRateLimit(&helper.RateLimit{RequestsPerSecond: 10, Burst: 1, MaxInFlight: 1})
+	app := NewApplication(settings, "live", "", "", "", "")
+	if _, err := app.Get(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := app.Get()
+		done <- err
+	}()
+	time.Sleep(30 * time.Millisecond)
+	if n := len(app.limiter(ts.URL).inFlight); n != 0 {
+		t.Errorf("expected a request waiting for the rate not to take an in-flight slot, %d taken", n)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	other := helper.NewDefaultSettings()
+	other.SetRateLimit(settings.RateLimit())
+	if len(other.HostStates(helper.RateLimitState)) != 0 {
+		t.Fatal("expected the limiters to be kept by each Settings")
+	}
+}
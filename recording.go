@@ -1,6 +1,8 @@
 package wserest
 
 import (
+	"context"
+
 	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
 	"github.com/sebastien4/wse-rest-library-go/entity/base"
 )
@@ -20,28 +22,7 @@ func NewRecording(settings *helper.Settings, appName string, appInstance string)
 	}
 	r := new(Recording)
 	r.init(settings)
-	r.props["recorderName"] = "myStream"
-	r.props["instanceName"] = "_definst_"
-	r.props["recorderState"] = "Waiting for stream"
-	r.props["defaultRecorder"] = true
-	r.props["segmentationType"] = "None"
-	r.props["outputPath"] = ""
-	r.props["baseFile"] = "myrecord.mp4"
-	r.props["fileFormat"] = "MP4"
-	r.props["fileVersionDelegateName"] = "com.wowza.wms.livestreamrecord.manager.StreamRecorderFileVersionDelegate"
-	r.props["fileTemplate"] = "${BaseFileName}_${RecordingStartTime}_${SegmentNumber}"
-	r.props["segmentDuration"] = 900000
-	r.props["segmentSize"] = 10485760
-	r.props["segmentSchedule"] = "0 * * * * *"
-	r.props["recordData"] = true
-	r.props["startOnKeyFrame"] = true
-	r.props["splitOnTcDiscontinuity"] = false
-	r.props["option"] = "Version existing file"
-	r.props["moveFirstVideoFrameToZero"] = true
-	r.props["currentSize"] = 0
-	r.props["currentDuration"] = 0
-	r.props["recordingStartTime"] = ""
-	r.baseURI = r.host() + "/servers/" + r.serverInstance() + "/vhosts/" + r.vHostInstance() + "/applications/" + appName + "/instances/" + appInstance + "/streamrecorders"
+	r.baseURI = r.vhostURI().join("applications", appName, "instances", appInstance, "streamrecorders").String()
 	return r
 }
 
@@ -69,93 +50,116 @@ func (r *Recording) Create(
 	currentDuration int,
 	recordingStartTime string,
 ) (map[string]interface{}, error) {
-	r.props["recorderName"] = recorderName
-	r.props["instanceName"] = instanceName
-	r.props["recorderState"] = recorderState
-	r.props["defaultRecorder"] = defaultRecorder
-	r.props["segmentationType"] = segmentationType
-	r.props["outputPath"] = outputPath
-	r.props["baseFile"] = baseFile
-	r.props["fileFormat"] = fileFormat
-	r.props["fileVersionDelegateName"] = fileVersionDelegateName
-	r.props["fileTemplate"] = fileTemplate
-	r.props["segmentDuration"] = segmentDuration
-	r.props["segmentSize"] = segmentSize
-	r.props["segmentSchedule"] = segmentSchedule
-	r.props["recordData"] = recordData
-	r.props["startOnKeyFrame"] = startOnKeyFrame
-	r.props["splitOnTcDiscontinuity"] = splitOnTcDiscontinuity
-	r.props["option"] = option
-	r.props["moveFirstVideoFrameToZero"] = moveFirstVideoFrameToZero
-	r.props["currentSize"] = currentSize
-	r.props["currentDuration"] = currentDuration
-	r.props["recordingStartTime"] = recordingStartTime
-
-	r.setRestURI(r.baseURI)
-	response, err := r.sendRequest(r.preparePropertiesForRequest(), []base.Entity{}, POST, "")
+	return r.CreateWithContext(context.Background(), recorderName, instanceName, recorderState, defaultRecorder, segmentationType, outputPath, baseFile, fileFormat, fileVersionDelegateName, fileTemplate, segmentDuration, segmentSize, segmentSchedule, recordData, startOnKeyFrame, splitOnTcDiscontinuity, option, moveFirstVideoFrameToZero, currentSize, currentDuration, recordingStartTime)
+}
+
+// CreateWithContext is like Create but honors ctx for cancellation and deadlines
+func (r *Recording) CreateWithContext(
+	ctx context.Context,
+	recorderName string,
+	instanceName string,
+	recorderState string,
+	defaultRecorder bool,
+	segmentationType string,
+	outputPath string,
+	baseFile string,
+	fileFormat string,
+	fileVersionDelegateName string,
+	fileTemplate string,
+	segmentDuration int,
+	segmentSize int,
+	segmentSchedule string,
+	recordData bool,
+	startOnKeyFrame bool,
+	splitOnTcDiscontinuity bool,
+	option string,
+	moveFirstVideoFrameToZero bool,
+	currentSize int,
+	currentDuration int,
+	recordingStartTime string,
+) (map[string]interface{}, error) {
+	props := requestProps(r.baseURI)
+	props["recorderName"] = recorderName
+	props["instanceName"] = instanceName
+	props["recorderState"] = recorderState
+	props["defaultRecorder"] = defaultRecorder
+	props["segmentationType"] = segmentationType
+	props["outputPath"] = outputPath
+	props["baseFile"] = baseFile
+	props["fileFormat"] = fileFormat
+	props["fileVersionDelegateName"] = fileVersionDelegateName
+	props["fileTemplate"] = fileTemplate
+	props["segmentDuration"] = segmentDuration
+	props["segmentSize"] = segmentSize
+	props["segmentSchedule"] = segmentSchedule
+	props["recordData"] = recordData
+	props["startOnKeyFrame"] = startOnKeyFrame
+	props["splitOnTcDiscontinuity"] = splitOnTcDiscontinuity
+	props["option"] = option
+	props["moveFirstVideoFrameToZero"] = moveFirstVideoFrameToZero
+	props["currentSize"] = currentSize
+	props["currentDuration"] = currentDuration
+	props["recordingStartTime"] = recordingStartTime
+
+	response, err := r.sendRequest(ctx, props, []base.Entity{}, POST, "")
 
 	return response, err
 }
 
 // GetAll retrieves the list of Stream Recorders
 func (r *Recording) GetAll() (map[string]interface{}, error) {
-	r.setNoParams()
+	return r.GetAllWithContext(context.Background())
+}
 
-	r.setRestURI(r.baseURI)
-	return r.sendRequest(r.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+// GetAllWithContext is like GetAll but honors ctx for cancellation and deadlines
+func (r *Recording) GetAllWithContext(ctx context.Context) (map[string]interface{}, error) {
+	return r.sendRequest(ctx, requestProps(r.baseURI), []base.Entity{}, GET, "")
 }
 
 // GetRecorder retrieves the specifed Stream Recorder
 func (r *Recording) GetRecorder(recorderName string) (map[string]interface{}, error) {
-	r.setRestURI(r.baseURI + "/" + recorderName)
-	r.setNoParams()
+	return r.GetRecorderWithContext(context.Background(), recorderName)
+}
+
+// GetRecorderWithContext is like GetRecorder but honors ctx for cancellation and deadlines
+func (r *Recording) GetRecorderWithContext(ctx context.Context, recorderName string) (map[string]interface{}, error) {
+	restURI := r.subURI(recorderName).String()
 
-	return r.sendRequest(r.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return r.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // GetDefaultParams retrieves a Stream Recorder of the requested name, popluated with the default values
 func (r *Recording) GetDefaultParams(recorderName string) (map[string]interface{}, error) {
-	r.setRestURI(r.baseURI + "/" + recorderName + "/default")
-	r.setNoParams()
+	return r.GetDefaultParamsWithContext(context.Background(), recorderName)
+}
+
+// GetDefaultParamsWithContext is like GetDefaultParams but honors ctx for cancellation and deadlines
+func (r *Recording) GetDefaultParamsWithContext(ctx context.Context, recorderName string) (map[string]interface{}, error) {
+	restURI := r.subURI(recorderName, "default").String()
 
-	return r.sendRequest(r.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return r.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // Stop stop recording
 func (r *Recording) Stop(recorderName string) (map[string]interface{}, error) {
-	r.setRestURI(r.baseURI + "/" + recorderName + "/actions/stopRecording")
-	r.setNoParams()
+	return r.StopWithContext(context.Background(), recorderName)
+}
+
+// StopWithContext is like Stop but honors ctx for cancellation and deadlines
+func (r *Recording) StopWithContext(ctx context.Context, recorderName string) (map[string]interface{}, error) {
+	restURI := r.subURI(recorderName, "actions", "stopRecording").String()
 
-	return r.sendRequest(r.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+	return r.sendRequest(ctx, requestProps(restURI), []base.Entity{}, PUT, "")
 }
 
 // Split splits recording
 func (r *Recording) Split(recorderName string) (map[string]interface{}, error) {
-	r.setRestURI(r.baseURI + "/" + recorderName + "/actions/splitRecording")
-	r.setNoParams()
-
-	return r.sendRequest(r.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+	return r.SplitWithContext(context.Background(), recorderName)
 }
 
-func (r *Recording) setNoParams() {
-	r.AddSkipParameter("recordName")
-	r.AddSkipParameter("instanceName")
-	r.AddSkipParameter("recorderState")
-	r.AddSkipParameter("defaultRecorder")
-	r.AddSkipParameter("segmentationType")
-	r.AddSkipParameter("outputPath")
-	r.AddSkipParameter("baseFile")
-	r.AddSkipParameter("fileFormat")
-	r.AddSkipParameter("fileVersionDelegateName")
-	r.AddSkipParameter("fileTemplate")
-	r.AddSkipParameter("segmentDuration")
-	r.AddSkipParameter("segmentSize")
-	r.AddSkipParameter("segmentSchedule")
-	r.AddSkipParameter("startOnKeyFrame")
-	r.AddSkipParameter("splitOnTcDiscontinuity")
-	r.AddSkipParameter("option")
-	r.AddSkipParameter("moveFirstVideoFrameToZero")
-	r.AddSkipParameter("currentSize")
-	r.AddSkipParameter("currentDuration")
-	r.AddSkipParameter("recordingStartTime")
+// SplitWithContext is like Split but honors ctx for cancellation and deadlines
+func (r *Recording) SplitWithContext(ctx context.Context, recorderName string) (map[string]interface{}, error) {
+	restURI := r.subURI(recorderName, "actions", "splitRecording").String()
+
+	return r.sendRequest(ctx, requestProps(restURI), []base.Entity{}, PUT, "")
 }
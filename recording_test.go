@@ -16,9 +16,7 @@ func TestRecording(t *testing.T) {
 
 	sf := NewRecording(settings, "", "")
 
-	if UseWowza == "" {
-		return
-	}
+	useCassette(t, settings, "recording")
 
 	response, err := sf.Split("myStream")
 	if err != nil {
@@ -0,0 +1,104 @@
+// Package redact hides the secrets of the requests and responses of the
+// Wowza Streaming Engine REST API: credentials, shared secrets and DRM keys.
+// It is used wherever they leave the process, in logs, dry runs and
+// cassettes.
+package redact
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Redacted replaces the value of a secret
+const Redacted = "[REDACTED]"
+
+// secretFields lists the JSON fields whose value is a secret, compared
+// case-insensitively
+var secretFields = map[string]bool{
+	"password":                      true,
+	"ezdrmpassword":                 true,
+	"buydrmuserkey":                 true,
+	"securetokensharedsecret":       true,
+	"securetokenoriginsharedsecret": true,
+	"dvrencryptionsharedsecret":     true,
+}
+
+// secretHeaders lists the headers whose value is a secret
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+type secretHeadersKey struct{}
+
+// IsSecretField reports whether the JSON field name holds a secret
+func IsSecretField(name string) bool {
+	return secretFields[strings.ToLower(name)]
+}
+
+// Body returns body with the value of the secret fields replaced, it is
+// returned unchanged when it is not JSON
+func Body(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	b, err := json.Marshal(value(v))
+	if err != nil {
+		return string(body)
+	}
+	return string(b)
+}
+
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if s, ok := val.(string); ok && s != "" && IsSecretField(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = value(val)
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = value(val)
+		}
+	}
+	return v
+}
+
+// Header returns a copy of header with the value of the secret headers
+// replaced, along with the ones named in secrets
+func Header(header http.Header, secrets ...string) http.Header {
+	h := header.Clone()
+	for _, names := range [][]string{secretHeaders, secrets} {
+		for _, name := range names {
+			if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+				h.Set(name, Redacted)
+			}
+		}
+	}
+	return h
+}
+
+// WithSecretHeaders returns a copy of ctx marking the headers named in
+// secrets as secret in the request it is sent with, such as the ones set by
+// an authenticator
+func WithSecretHeaders(ctx context.Context, secrets ...string) context.Context {
+	if len(secrets) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, secretHeadersKey{}, append(append([]string(nil), SecretHeaders(ctx)...), secrets...))
+}
+
+// SecretHeaders returns the headers marked as secret in ctx
+func SecretHeaders(ctx context.Context) []string {
+	secrets, _ := ctx.Value(secretHeadersKey{}).([]string)
+	return secrets
+}
+
+// RequestHeader returns a copy of the header of req with the value of the
+// secret headers replaced, those marked in its context included
+func RequestHeader(req *http.Request) http.Header {
+	return Header(req.Header, SecretHeaders(req.Context())...)
+}
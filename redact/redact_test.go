@@ -0,0 +1,91 @@
+package redact
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application"
+)
+
+func TestBody(t *testing.T) {
+	got := Body([]byte(`{"name":"live","drmConfig":{"ezDRMPassword":"p","buyDRMUserKey":"k","verimatrixCupertinoKeyServerIpAddress":"10.0.0.1"},"users":[{"password":"p"},{"password":""}]}`))
+	want := `{"drmConfig":{"buyDRMUserKey":"[REDACTED]","ezDRMPassword":"[REDACTED]","verimatrixCupertinoKeyServerIpAddress":"10.0.0.1"},"name":"live","users":[{"password":"[REDACTED]"},{"password":""}]}`
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	if got := Body([]byte("password=secret")); got != "password=secret" {
+		t.Fatalf("expected a body that is not JSON to be unchanged, got %s", got)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	header := http.Header{"Authorization": {"Basic YWRtaW46YWRtaW4="}, "Accept": {"application/json"}}
+	got := Header(header)
+	if got.Get("Authorization") != Redacted || got.Get("Accept") != "application/json" {
+		t.Fatalf("got %v", got)
+	}
+	if header.Get("Authorization") == Redacted {
+		t.Fatal("expected the header to be copied")
+	}
+}
+
+func TestRequestHeader(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost:8087/v2", nil)
+	req.Header.Set("X-Api-Key", "topsecret")
+	req.Header.Set("Accept", "application/json")
+	if got := RequestHeader(req); got.Get("X-Api-Key") != "topsecret" {
+		t.Fatalf("expected an unmarked header to be kept, got %v", got)
+	}
+	req = req.WithContext(WithSecretHeaders(req.Context(), "x-api-key"))
+	if got := RequestHeader(req); got.Get("X-Api-Key") != Redacted || got.Get("Accept") != "application/json" {
+		t.Fatalf("got %v", got)
+	}
+}
+
+// notSecretFields lists the string fields of the entities named like
+// secrets that are not
+var notSecretFields = map[string]bool{
+	"publishpasswordfile":                   true,
+	"verimatrixcupertinokeyserveripaddress": true,
+	"verimatrixsmoothkeyserveripaddress":    true,
+}
+
+func TestSecretFieldsCoverEntities(t *testing.T) {
+	seen := make(map[reflect.Type]bool)
+	var walk func(reflect.Type)
+	walk = func(typ reflect.Type) {
+		for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct || seen[typ] {
+			return
+		}
+		seen[typ] = true
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			name := strings.ToLower(strings.Split(field.Tag.Get("json"), ",")[0])
+			if field.Type.Kind() == reflect.String && !IsSecretField(name) && !notSecretFields[name] &&
+				(strings.Contains(name, "secret") || strings.Contains(name, "password") || strings.Contains(name, "key")) {
+				t.Errorf("%s.%s looks like a secret, add %q to secretFields or notSecretFields", typ.Name(), field.Name, name)
+			}
+			walk(field.Type)
+		}
+	}
+	for _, entity := range []interface{}{
+		&application.AdvancedSettings{},
+		application.NewDrmConfig(),
+		application.NewDvrConfig(),
+		application.NewModules(),
+		application.NewSecurityConfig(),
+		application.NewStreamConfig(),
+		application.NewStreamFiles(),
+		application.NewTranscoderConfig(),
+	} {
+		walk(reflect.TypeOf(entity))
+	}
+	if len(seen) < 8 {
+		t.Fatalf("expected every entity to be walked, got %d types", len(seen))
+	}
+}
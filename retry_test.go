@@ -0,0 +1,140 @@
+package wserest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// flakyServer fails the first failures requests with status, or by closing
+// the connection when status is 0
+func flakyServer(failures int32, status int, header http.Header) (*httptest.Server, *int32) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) > failures {
+			w.Write([]byte(`{"success":true}`))
+			return
+		}
+		if status == 0 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+	}))
+	return ts, &calls
+}
+
+func newRetryTestSettings(url string) *helper.Settings {
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(url + "/v2")
+	policy := helper.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	settings.SetRetryPolicy(policy)
+	return settings
+}
+
+func TestRetry(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		failures int32
+		status   int
+		calls    int32
+		success  bool
+	}{
+		{"unavailable", 2, http.StatusServiceUnavailable, 3, true},
+		{"connection reset", 1, 0, 2, true},
+		{"too many failures", 5, http.StatusBadGateway, 3, false},
+		{"not retryable", 1, http.StatusInternalServerError, 1, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, calls := flakyServer(tt.failures, tt.status, nil)
+			defer ts.Close()
+
+			_, err := NewApplication(newRetryTestSettings(ts.URL), "live", "", "", "", "").Get()
+			if (err == nil) != tt.success {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if *calls != tt.calls {
+				t.Errorf("expected %d calls, got %d", tt.calls, *calls)
+			}
+		})
+	}
+}
+
+func TestRetryPOST(t *testing.T) {
+	ts, calls := flakyServer(1, http.StatusServiceUnavailable, nil)
+	defer ts.Close()
+
+	settings := newRetryTestSettings(ts.URL)
+	app := NewApplication(settings, "live", "", "", "", "")
+	if _, err := app.Create(nil, nil, nil, nil, nil, nil); StatusCode(err) != http.StatusServiceUnavailable {
+		t.Fatalf("expected POST not to be retried, got %v", err)
+	}
+
+	settings.RetryPolicy().RetryPOST = true
+	if _, err := app.Create(nil, nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if *calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", *calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	ts, _ := flakyServer(1, http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}})
+	defer ts.Close()
+
+	settings := newRetryTestSettings(ts.URL)
+	start := time.Now()
+	if _, err := NewApplication(settings, "live", "", "", "", "").Get(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected Retry-After to be honored, retried after %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	ts2, calls := flakyServer(1, http.StatusServiceUnavailable, http.Header{"Retry-After": {"60"}})
+	defer ts2.Close()
+	_, err := NewApplication(newRetryTestSettings(ts2.URL), "live", "", "", "", "").GetWithContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) || *calls != 1 {
+		t.Fatalf("expected cancellation while waiting, got %v after %d calls", err, *calls)
+	}
+}
+
+func TestRetryAfterCapped(t *testing.T) {
+	ts, calls := flakyServer(1, http.StatusServiceUnavailable, http.Header{"Retry-After": {"86400"}})
+	defer ts.Close()
+
+	settings := newRetryTestSettings(ts.URL)
+	settings.RetryPolicy().MaxBackoff = 50 * time.Millisecond
+	start := time.Now()
+	if _, err := NewApplication(settings, "live", "", "", "", "").Get(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > time.Second || *calls != 2 {
+		t.Fatalf("expected Retry-After to be capped by the maximum backoff, retried after %s and %d calls", elapsed, *calls)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &helper.RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	for retry, bound := range []time.Duration{0, 100, 200, 300, 300} {
+		for i := 0; i < 20 && retry > 0; i++ {
+			if d := policy.Backoff(retry); d < 0 || d > bound*time.Millisecond {
+				t.Fatalf("backoff of retry %d is %s, expected at most %dms", retry, d, bound)
+			}
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package wserest
 
 import (
+	"context"
+
 	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
 	"github.com/sebastien4/wse-rest-library-go/entity/base"
 )
@@ -14,30 +16,69 @@ type Server struct {
 func NewServer(settings *helper.Settings) *Server {
 	s := new(Server)
 	s.init(settings)
-	s.baseURI = s.host() + "/servers/" + s.serverInstance()
+	s.baseURI = s.serverURI().String()
 	return s
 }
 
 // GetUsers retrieves the list of server Users
 func (s *Server) GetUsers() (map[string]interface{}, error) {
-	s.setRestURI(s.baseURI + "/users")
+	return s.GetUsersWithContext(context.Background())
+}
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+// GetUsersWithContext is like GetUsers but honors ctx for cancellation and deadlines
+func (s *Server) GetUsersWithContext(ctx context.Context) (map[string]interface{}, error) {
+	restURI := s.subURI("users").String()
+
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // CreateUser adds a new server User to the list
 func (s *Server) CreateUser(name string, password string, groups []string) (map[string]interface{}, error) {
-	s.setRestURI(s.baseURI + "/users/" + name)
-	s.AddAdditionalParameter("name", name)
-	s.AddAdditionalParameter("password", password)
-	s.AddAdditionalParameter("groups", groups)
+	return s.CreateUserWithContext(context.Background(), name, password, groups)
+}
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, POST, "")
+// CreateUserWithContext is like CreateUser but honors ctx for cancellation and deadlines
+func (s *Server) CreateUserWithContext(ctx context.Context, name string, password string, groups []string) (map[string]interface{}, error) {
+	props := requestProps(s.subURI("users", name).String())
+	props["name"] = name
+	props["password"] = password
+	props["groups"] = groups
+
+	return s.sendRequest(ctx, props, []base.Entity{}, POST, "")
 }
 
 // RemoveUser deletes the specified User configuration
 func (s *Server) RemoveUser(name string) (map[string]interface{}, error) {
-	s.setRestURI(s.baseURI + "/users/" + name)
+	return s.RemoveUserWithContext(context.Background(), name)
+}
+
+// RemoveUserWithContext is like RemoveUser but honors ctx for cancellation and deadlines
+func (s *Server) RemoveUserWithContext(ctx context.Context, name string) (map[string]interface{}, error) {
+	restURI := s.subURI("users", name).String()
+
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, DELETE, "")
+}
+
+// Version retrieves the Wowza Streaming Engine version of the server, it is
+// learnt from the Server header or the version field of the responses and
+// kept per host in helper.Settings
+func (s *Server) Version() (ServerVersion, error) {
+	return s.VersionWithContext(context.Background())
+}
+
+// VersionWithContext is like Version but honors ctx for cancellation and deadlines
+func (s *Server) VersionWithContext(ctx context.Context) (ServerVersion, error) {
+	return s.serverVersion(ctx)
+}
+
+// RequireVersion returns an error wrapping ErrUnsupportedByServer when the
+// server runs a Wowza Streaming Engine version older than min, for callers
+// relying on a feature introduced by that version
+func (s *Server) RequireVersion(min ServerVersion) error {
+	return s.RequireVersionWithContext(context.Background(), min)
+}
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, DELETE, "")
+// RequireVersionWithContext is like RequireVersion but honors ctx for cancellation and deadlines
+func (s *Server) RequireVersionWithContext(ctx context.Context, min ServerVersion) error {
+	return s.requireVersion(ctx, min)
 }
@@ -0,0 +1,162 @@
+package wserest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// ErrUnsupportedByServer is returned, wrapped, when a call needs a feature
+// that the Wowza Streaming Engine version of the host does not provide
+var ErrUnsupportedByServer = errors.New("unsupported by server")
+
+// ServerVersion is a Wowza Streaming Engine version
+type ServerVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+var versionRegex = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseServerVersion parses the first version found in s, such as
+// "WowzaStreamingEngine/4.8.5" or "4.7.7.01+1 build20190417"
+func ParseServerVersion(s string) (ServerVersion, error) {
+	m := versionRegex.FindStringSubmatch(s)
+	if m == nil {
+		return ServerVersion{}, fmt.Errorf("failed to parse server version %q", s)
+	}
+	var v ServerVersion
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	return v, nil
+}
+
+func (v ServerVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less reports whether v is older than o
+func (v ServerVersion) Less(o ServerVersion) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+// feature is a part of the REST API introduced by a server version
+type feature struct {
+	name  string
+	since ServerVersion
+}
+
+// featureDVRClipExtraction is the dvrstores resource of DvrClipExtraction.
+// Wowza documents the nDVR clip extraction REST API for Wowza Streaming
+// Engine 4.1.0 and later, it was introduced by the 4.1.0 release.
+var featureDVRClipExtraction = &feature{name: "DVR clip extraction", since: ServerVersion{4, 1, 0}}
+
+// checkFeature returns an error wrapping ErrUnsupportedByServer, and err if
+// any, when the version known for the host of w is older than the feature
+// of w needs. Otherwise err is returned as is: the version is not probed,
+// the response of a first call tells it.
+func (w *wowza) checkFeature(err error) error {
+	if w.feature == nil {
+		return err
+	}
+	v, ok := w.knownServerVersion()
+	if !ok || !v.Less(w.feature.since) {
+		return err
+	}
+	unsupported := fmt.Errorf("%w: %s requires Wowza Streaming Engine %s or later, the server runs %s", ErrUnsupportedByServer, w.feature.name, w.feature.since, v)
+	if err != nil {
+		return fmt.Errorf("%w: %w", unsupported, err)
+	}
+	return unsupported
+}
+
+// observeServerVersion records the version of the host of w announced by a
+// response: the Server header, or the version field of the body when a
+// proxy left the header out
+func (w *wowza) observeServerVersion(resp *helper.Response) {
+	server := resp.Header.Get("Server")
+	if strings.Contains(strings.ToLower(server), "wowza") {
+		if v, err := ParseServerVersion(server); err == nil {
+			w.settings.SetServerVersion(hostOf(w.host()), v.String())
+			return
+		}
+	}
+	var body struct {
+		Version string `json:"version"`
+	}
+	if json.Unmarshal(resp.Body, &body) != nil || !serverVersionRegex.MatchString(body.Version) {
+		return
+	}
+	if v, err := ParseServerVersion(body.Version); err == nil {
+		w.settings.SetServerVersion(hostOf(w.host()), v.String())
+	}
+}
+
+// serverVersionRegex matches a whole server version, unlike the version of
+// a configuration that is a timestamp
+var serverVersionRegex = regexp.MustCompile(`^\d+\.\d+(\.\d+)*([+ ].*)?$`)
+
+// knownServerVersion returns the version learnt for the host of w
+func (w *wowza) knownServerVersion() (ServerVersion, bool) {
+	version := w.settings.ServerVersion(hostOf(w.host()))
+	if version == "" {
+		return ServerVersion{}, false
+	}
+	v, err := ParseServerVersion(version)
+	return v, err == nil
+}
+
+// serverVersion returns the version of the host of w, probing the server
+// when none of its responses was seen yet
+func (w *wowza) serverVersion(ctx context.Context) (ServerVersion, error) {
+	if v, ok := w.knownServerVersion(); ok {
+		return v, nil
+	}
+
+	restURI := w.serverURI().String()
+	body, err := json.Marshal(requestProps(restURI))
+	if err != nil {
+		return ServerVersion{}, err
+	}
+	req := &helper.Request{Method: GET.String(), URI: restURI, Header: make(http.Header), Body: body}
+	contents := make(map[string]interface{})
+	// the version is read from the headers, even those of an error response
+	_, err = w.call(ctx, req, &contents)
+	if v, ok := w.knownServerVersion(); ok {
+		return v, nil
+	}
+	if err != nil {
+		return ServerVersion{}, fmt.Errorf("failed to probe the server version: %w", err)
+	}
+	return ServerVersion{}, fmt.Errorf("failed to detect the server version of %s", hostOf(w.host()))
+}
+
+// requireVersion returns an error wrapping ErrUnsupportedByServer when the
+// server is older than min
+func (w *wowza) requireVersion(ctx context.Context, min ServerVersion) error {
+	v, err := w.serverVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if v.Less(min) {
+		return fmt.Errorf("%w: Wowza Streaming Engine %s or later is required, the server runs %s", ErrUnsupportedByServer, min, v)
+	}
+	return nil
+}
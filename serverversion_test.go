@@ -0,0 +1,163 @@
+package wserest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ServerVersion
+		err  bool
+	}{
+		{in: "WowzaStreamingEngine/4.8.5", want: ServerVersion{4, 8, 5}},
+		{in: "4.7.7.01+1 build20190417", want: ServerVersion{4, 7, 7}},
+		{in: "Wowza Streaming Engine 4 Perpetual Edition 4.8.0 build20191129", want: ServerVersion{4, 8, 0}},
+		{in: "WowzaStreamingEngine/5.0", want: ServerVersion{5, 0, 0}},
+		{in: "WowzaStreamingEngine", err: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseServerVersion(tt.in)
+		if (err != nil) != tt.err || got != tt.want {
+			t.Errorf("%q: got %v, %v", tt.in, got, err)
+		}
+	}
+
+	if !(ServerVersion{4, 7, 9}).Less(ServerVersion{4, 8, 0}) || (ServerVersion{4, 8, 0}).Less(ServerVersion{4, 8, 0}) {
+		t.Fatal("unexpected version ordering")
+	}
+}
+
+// versionServer answers every request announcing version in its Server
+// header and counts the requests
+func versionServer(version string) (*httptest.Server, *int32) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Server", version)
+		w.Write([]byte(`{"serverName":"_defaultServer_","dvrconverterstoresummary":[]}`))
+	}))
+	return ts, &calls
+}
+
+func versionSettings(url string) *helper.Settings {
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(url + "/v2")
+	return settings
+}
+
+func TestServerRequireVersion(t *testing.T) {
+	old, oldCalls := versionServer("WowzaStreamingEngine/4.0.8")
+	defer old.Close()
+
+	settings := versionSettings(old.URL)
+	server := NewServer(settings)
+	if err := server.RequireVersion(ServerVersion{4, 7, 0}); !errors.Is(err, ErrUnsupportedByServer) {
+		t.Fatalf("expected ErrUnsupportedByServer, got %v", err)
+	}
+	if err := server.RequireVersion(ServerVersion{4, 0, 8}); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(oldCalls); n != 1 {
+		t.Fatalf("expected a single probe, got %d requests", n)
+	}
+
+	// the versions are kept per settings
+	if _, err := NewServer(versionSettings(old.URL)).Version(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(oldCalls); n != 2 {
+		t.Fatalf("expected other settings to probe again, got %d requests", n)
+	}
+}
+
+func TestServerVersionObserved(t *testing.T) {
+	ts, calls := versionServer("WowzaStreamingEngine/4.7.7")
+	defer ts.Close()
+
+	settings := versionSettings(ts.URL)
+	if _, err := NewDvrClipExtraction(settings, "live", "").GetAll(); err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewServer(settings).Version()
+	if err != nil || v != (ServerVersion{4, 7, 7}) {
+		t.Fatalf("got version %v, %v", v, err)
+	}
+	if n := atomic.LoadInt32(calls); n != 1 {
+		t.Fatalf("expected the version to be learnt from the first response, got %d requests", n)
+	}
+
+	settings.SetServerVersion(hostOf(ts.URL+"/v2"), "")
+	if _, err = NewServer(settings).Version(); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(calls); n != 2 {
+		t.Fatalf("expected a probe once the version is forgotten, got %d requests", n)
+	}
+}
+
+func TestServerVersionFromBody(t *testing.T) {
+	// a proxy dropped the Server header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "nginx/1.25.3")
+		w.Write([]byte(`{"serverName":"_defaultServer_","version":"4.8.5+3 build20200303"}`))
+	}))
+	defer ts.Close()
+
+	v, err := NewServer(versionSettings(ts.URL)).Version()
+	if err != nil || v != (ServerVersion{4, 8, 5}) {
+		t.Fatalf("got version %v, %v", v, err)
+	}
+}
+
+func TestServerVersionUnknown(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the version of a configuration is not the one of the server
+		w.Write([]byte(`{"serverName":"_defaultServer_","version":"1430601267443"}`))
+	}))
+	defer ts.Close()
+
+	server := NewServer(versionSettings(ts.URL))
+	if _, err := server.Version(); err == nil {
+		t.Fatal("expected an error without a server version")
+	}
+	if err := server.RequireVersion(ServerVersion{4, 0, 0}); err == nil || errors.Is(err, ErrUnsupportedByServer) {
+		t.Fatalf("expected the detection to fail, got %v", err)
+	}
+}
+
+func TestFeatureGating(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Server", "WowzaStreamingEngine/4.0.8")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"success":false,"message":"Not found"}`))
+	}))
+	defer ts.Close()
+
+	dvr := NewDvrClipExtraction(versionSettings(ts.URL), "live", "")
+	// the version is unknown, the call is sent and its failure explained
+	_, err := dvr.GetAll()
+	if !errors.Is(err, ErrUnsupportedByServer) || !IsNotFound(err) {
+		t.Fatalf("expected a not found error wrapping ErrUnsupportedByServer, got %v", err)
+	}
+	// the version is known, the call fails without being sent
+	if _, err = dvr.Convert("store", 0, 0, "", "", false); !errors.Is(err, ErrUnsupportedByServer) {
+		t.Fatalf("expected ErrUnsupportedByServer, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("expected the unsupported call not to be sent, got %d requests", n)
+	}
+
+	// resources without a feature are not gated
+	if _, err = NewApplication(dvr.settings, "live", "", "", "", "").Get(); errors.Is(err, ErrUnsupportedByServer) || !IsNotFound(err) {
+		t.Fatalf("expected a plain not found error, got %v", err)
+	}
+}
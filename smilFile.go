@@ -1,6 +1,8 @@
 package wserest
 
 import (
+	"context"
+
 	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
 	"github.com/sebastien4/wse-rest-library-go/entity/base"
 )
@@ -14,41 +16,55 @@ type SmilFile struct {
 func NewSmilFile(settings *helper.Settings, appName string) *SmilFile {
 	s := new(SmilFile)
 	s.init(settings)
-	s.props["smilStreams"] = []string{}
-	s.baseURI = s.host() + "/servers/" + s.serverInstance() + "/vhosts/" + s.vHostInstance() + "/applications/" + appName + "/smilfiles"
+	s.baseURI = s.vhostURI().join("applications", appName, "smilfiles").String()
 	return s
 }
 
 // Create adds the specified SMIL File configuration
 func (s *SmilFile) Create(fileName string, streams []map[string]interface{}) (map[string]interface{}, error) {
-	s.setRestURI(s.baseURI + "/" + fileName)
-	s.props["smilStreams"] = streams
+	return s.CreateWithContext(context.Background(), fileName, streams)
+}
 
-	response, err := s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, POST, "")
+// CreateWithContext is like Create but honors ctx for cancellation and deadlines
+func (s *SmilFile) CreateWithContext(ctx context.Context, fileName string, streams []map[string]interface{}) (map[string]interface{}, error) {
+	props := requestProps(s.subURI(fileName).String())
+	props["smilStreams"] = streams
+
+	response, err := s.sendRequest(ctx, props, []base.Entity{}, POST, "")
 
 	return response, err
 }
 
 // Get retrieves the specified SMIL File configuration
 func (s *SmilFile) Get(fileName string) (map[string]interface{}, error) {
-	s.AddSkipParameter("smilStreams")
-	s.setRestURI(s.baseURI + "/" + fileName)
+	return s.GetWithContext(context.Background(), fileName)
+}
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+// GetWithContext is like Get but honors ctx for cancellation and deadlines
+func (s *SmilFile) GetWithContext(ctx context.Context, fileName string) (map[string]interface{}, error) {
+	restURI := s.subURI(fileName).String()
+
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // GetAll retrieves the list of SMIL Files for the specified Application
 func (s *SmilFile) GetAll() (map[string]interface{}, error) {
-	s.AddSkipParameter("smilStreams")
-	s.setRestURI(s.baseURI)
+	return s.GetAllWithContext(context.Background())
+}
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+// GetAllWithContext is like GetAll but honors ctx for cancellation and deadlines
+func (s *SmilFile) GetAllWithContext(ctx context.Context) (map[string]interface{}, error) {
+	return s.sendRequest(ctx, requestProps(s.baseURI), []base.Entity{}, GET, "")
 }
 
 // Remove deletes the specified SMIL File configuration
 func (s *SmilFile) Remove(fileName string) (map[string]interface{}, error) {
-	s.AddSkipParameter("smilStreams")
-	s.setRestURI(s.baseURI + "/" + fileName)
+	return s.RemoveWithContext(context.Background(), fileName)
+}
+
+// RemoveWithContext is like Remove but honors ctx for cancellation and deadlines
+func (s *SmilFile) RemoveWithContext(ctx context.Context, fileName string) (map[string]interface{}, error) {
+	restURI := s.subURI(fileName).String()
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, DELETE, "")
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, DELETE, "")
 }
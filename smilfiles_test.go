@@ -16,9 +16,7 @@ func TestSmilFiles(t *testing.T) {
 
 	sf := NewSmilFile(settings, "live")
 
-	if UseWowza == "" {
-		return
-	}
+	useCassette(t, settings, "smilfiles")
 
 	/*
 	   * [
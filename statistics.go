@@ -1,7 +1,7 @@
 package wserest
 
 import (
-	"strings"
+	"context"
 
 	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
 	"github.com/sebastien4/wse-rest-library-go/entity/base"
@@ -22,39 +22,64 @@ func NewStatistics(
 
 // GetApplicationStatistics retrieves the current Application statistics
 func (s *Statistics) GetApplicationStatistics(application *Application) (map[string]interface{}, error) {
-	s.setRestURI(application.baseURI + "/monitoring/current")
+	return s.GetApplicationStatisticsWithContext(context.Background(), application)
+}
+
+// GetApplicationStatisticsWithContext is like GetApplicationStatistics but honors ctx for cancellation and deadlines
+func (s *Statistics) GetApplicationStatisticsWithContext(ctx context.Context, application *Application) (map[string]interface{}, error) {
+	restURI := application.subURI("monitoring", "current").String()
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // GetApplicationStatisticsHistory retrieves the historic Application statistics
 func (s *Statistics) GetApplicationStatisticsHistory(application *Application) (map[string]interface{}, error) {
-	s.setRestURI(application.baseURI + "/monitoring/historic")
+	return s.GetApplicationStatisticsHistoryWithContext(context.Background(), application)
+}
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+// GetApplicationStatisticsHistoryWithContext is like GetApplicationStatisticsHistory but honors ctx for cancellation and deadlines
+func (s *Statistics) GetApplicationStatisticsHistoryWithContext(ctx context.Context, application *Application) (map[string]interface{}, error) {
+	restURI := application.subURI("monitoring", "historic").String()
+
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // GetIncomingApplicationStatistics retrieves the Current Incoming Stream statistics for the specifed Incoming Stream
 func (s *Statistics) GetIncomingApplicationStatistics(application *Application, streamName string, appInstance string) (map[string]interface{}, error) {
+	return s.GetIncomingApplicationStatisticsWithContext(context.Background(), application, streamName, appInstance)
+}
+
+// GetIncomingApplicationStatisticsWithContext is like GetIncomingApplicationStatistics but honors ctx for cancellation and deadlines
+func (s *Statistics) GetIncomingApplicationStatisticsWithContext(ctx context.Context, application *Application, streamName string, appInstance string) (map[string]interface{}, error) {
 	if appInstance == "" {
 		appInstance = "_definst_"
 	}
-	s.setRestURI(application.baseURI + "/instances/" + appInstance + "/incomingstreams/" + streamName + "/monitoring/current")
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	restURI := application.subURI("instances", appInstance, "incomingstreams", streamName, "monitoring", "current").String()
+
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // GetServerStatistics retrieves the server historical statictics
 func (s *Statistics) GetServerStatistics(server *Server) (map[string]interface{}, error) {
-	s.setRestURI(server.baseURI + "/monitoring/historic")
+	return s.GetServerStatisticsWithContext(context.Background(), server)
+}
+
+// GetServerStatisticsWithContext is like GetServerStatistics but honors ctx for cancellation and deadlines
+func (s *Statistics) GetServerStatisticsWithContext(ctx context.Context, server *Server) (map[string]interface{}, error) {
+	restURI := server.subURI("monitoring", "historic").String()
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // GetServerStatisticsCurrent retrieves current statictics for the machine
 func (s *Statistics) GetServerStatisticsCurrent(server *Server) (map[string]interface{}, error) {
-	restURI := strings.Split("/servers/", server.baseURI)
-	s.setRestURI(restURI[0] + "/machine/monitoring/current")
+	return s.GetServerStatisticsCurrentWithContext(context.Background(), server)
+}
+
+// GetServerStatisticsCurrentWithContext is like GetServerStatisticsCurrent but honors ctx for cancellation and deadlines
+func (s *Statistics) GetServerStatisticsCurrentWithContext(ctx context.Context, server *Server) (map[string]interface{}, error) {
+	restURI := newURI(server.host()).join("machine", "monitoring", "current").String()
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
@@ -16,9 +16,7 @@ func TestStatistics(t *testing.T) {
 
 	sf := NewStatistics(settings)
 
-	if UseWowza == "" {
-		return
-	}
+	useCassette(t, settings, "statistics")
 
 	// get stats per application
 	wowzaApplication := NewApplication(settings, "vod", "", "", "", "")
@@ -1,7 +1,11 @@
 package wserest
 
 import (
+	"context"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/sebastien4/wse-rest-library-go/entity/application"
 	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
@@ -11,7 +15,11 @@ import (
 // StreamFile is Stream File utility
 type StreamFile struct {
 	wowza
-	applicationName     string
+	applicationName string
+	name            string
+
+	// set by Create and used by Connect, Disconnect and Reset
+	mu                  sync.RWMutex
 	mediaCasterType     string
 	applicationInstance string
 }
@@ -20,68 +28,93 @@ type StreamFile struct {
 func NewStreamFile(settings *helper.Settings, appName, streamFileName string) *StreamFile {
 	s := new(StreamFile)
 	s.init(settings)
-	s.baseURI = s.host() + "/servers/" + s.serverInstance() + "/vhosts/" + s.vHostInstance() + "/applications/" + appName + "/streamfiles"
+	s.baseURI = s.vhostURI().join("applications", appName, "streamfiles").String()
 
 	if appName != "" {
 		s.applicationName = appName
 	}
 
-	if streamFileName != "" {
-		s.props["name"] = streamFileName
-	}
+	s.name = streamFileName
+	s.mediaCasterType = "rtp"
+	s.applicationInstance = "_definst_"
 
 	return s
 }
 
+// connection returns the media caster type and application instance of the
+// last Create
+func (s *StreamFile) connection() (string, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mediaCasterType, s.applicationInstance
+}
+
 // Get retrieves the specified Stream File configuration
 func (s *StreamFile) Get() (map[string]interface{}, error) {
-	s.AddSkipParameter("name")
-	s.setRestURI(s.baseURI + "/" + s.props["name"].(string))
+	return s.GetWithContext(context.Background())
+}
+
+// GetWithContext is like Get but honors ctx for cancellation and deadlines
+func (s *StreamFile) GetWithContext(ctx context.Context) (map[string]interface{}, error) {
+	restURI := s.subURI(s.name).String()
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, GET, "")
 }
 
 // GetAll retrieves the list of Stream Files for the specified VHost
 func (s *StreamFile) GetAll() (map[string]interface{}, error) {
-	s.AddSkipParameter("name")
-	s.setRestURI(s.baseURI)
+	return s.GetAllWithContext(context.Background())
+}
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+// GetAllWithContext is like GetAll but honors ctx for cancellation and deadlines
+func (s *StreamFile) GetAllWithContext(ctx context.Context) (map[string]interface{}, error) {
+	return s.sendRequest(ctx, requestProps(s.baseURI), []base.Entity{}, GET, "")
 }
 
 // Create adds the specified Stream File configuration
 func (s *StreamFile) Create(urlProps map[string]interface{}, mediaCasterType string, applicationInstance string) (map[string]interface{}, error) {
+	return s.CreateWithContext(context.Background(), urlProps, mediaCasterType, applicationInstance)
+}
+
+// CreateWithContext is like Create but honors ctx for cancellation and deadlines
+func (s *StreamFile) CreateWithContext(ctx context.Context, urlProps map[string]interface{}, mediaCasterType string, applicationInstance string) (response map[string]interface{}, err error) {
+	ctx, span := s.startOperation(ctx, "StreamFile.Create")
+	defer func() { span.End(err) }()
+
 	if mediaCasterType == "" {
 		mediaCasterType = "rtp"
 	}
 	if applicationInstance == "" {
 		applicationInstance = "_definst_"
 	}
+	s.mu.Lock()
 	s.mediaCasterType = mediaCasterType
 	s.applicationInstance = applicationInstance
+	s.mu.Unlock()
 	sf := application.NewStreamFiles()
 	sf.ID = "connectAppName=" + s.applicationName + "&appInstance=" + applicationInstance + "&mediaCasterType=" + mediaCasterType
-	sf.Href = s.baseURI + "/streamfiles/" + sf.ID
+	sf.Href = s.subURI(sf.ID).String()
 
 	entities := s.getEntities([]base.Entity{sf}, "")
-	s.setRestURI(s.baseURI + "/" + s.props["name"].(string))
-	response, err := s.sendRequest(s.preparePropertiesForRequest(), entities, POST, "")
+	restURI := s.subURI(s.name).String()
+	props := requestProps(restURI)
+	props["name"] = s.name
+	response, err = s.sendRequest(ctx, props, entities, POST, "")
 	if err == nil {
 		items := s.getAdvancedSettings(urlProps)
 
-		return s.addURL(items)
+		return s.addURL(ctx, restURI, items)
 	}
 
 	return response, err
 }
 
-func (s *StreamFile) addURL(advancedSettings []*helper.AdvancedSettingItem) (map[string]interface{}, error) {
-	s.AddSkipParameter("name")
-	s.setRestURI(s.props["restURI"].(string) + "/adv")
-	s.AddAdditionalParameter("version", "1430601267443")
-	s.AddAdditionalParameter("advancedSettings", advancedSettings)
+func (s *StreamFile) addURL(ctx context.Context, restURI string, advancedSettings []*helper.AdvancedSettingItem) (map[string]interface{}, error) {
+	props := requestProps(newURI(restURI).join("adv").String())
+	props["version"] = "1430601267443"
+	props["advancedSettings"] = advancedSettings
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+	return s.sendRequest(ctx, props, []base.Entity{}, PUT, "")
 }
 
 func (s *StreamFile) getAdvancedSettings(urlProps map[string]interface{}) []*helper.AdvancedSettingItem {
@@ -109,41 +142,60 @@ func (s *StreamFile) getAdvancedSettings(urlProps map[string]interface{}) []*hel
 
 // Update updates the Advanced Stream File configuration
 func (s *StreamFile) Update(urlProps map[string]interface{}) (map[string]interface{}, error) {
-	s.setRestURI(s.baseURI + "/" + s.props["name"].(string))
+	return s.UpdateWithContext(context.Background(), urlProps)
+}
+
+// UpdateWithContext is like Update but honors ctx for cancellation and deadlines
+func (s *StreamFile) UpdateWithContext(ctx context.Context, urlProps map[string]interface{}) (map[string]interface{}, error) {
 	items := s.getAdvancedSettings(urlProps)
 
-	return s.addURL(items)
+	return s.addURL(ctx, s.subURI(s.name).String(), items)
 }
 
 // Remove deletes the specified Stream File configuration
 func (s *StreamFile) Remove() (map[string]interface{}, error) {
-	s.AddSkipParameter("name")
-	s.setRestURI(s.baseURI + "/" + s.props["name"].(string))
+	return s.RemoveWithContext(context.Background())
+}
+
+// RemoveWithContext is like Remove but honors ctx for cancellation and deadlines
+func (s *StreamFile) RemoveWithContext(ctx context.Context) (map[string]interface{}, error) {
+	restURI := s.subURI(s.name).String()
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, DELETE, "")
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, DELETE, "")
 }
 
-// Connect connects
+// Connect connects the stream file found in subFolder, if any. The media
+// caster type and application instance are those of the last Create, "rtp"
+// and "_definst_" when the stream file was not created with this StreamFile.
 func (s *StreamFile) Connect(subFolder string) (map[string]interface{}, error) {
-	s.AddSkipParameter("name")
-	//	s.AddAdditionalParameter("connectAppName", s.applicationName)
-	//	s.AddAdditionalParameter("appInstance", s.applicationInstance)
-	//	s.AddAdditionalParameter("mediaCasterType", s.mediaCasterType)
-	streamFilePath := ""
+	return s.ConnectWithContext(context.Background(), subFolder)
+}
+
+// ConnectWithContext is like Connect but honors ctx for cancellation and deadlines
+func (s *StreamFile) ConnectWithContext(ctx context.Context, subFolder string) (map[string]interface{}, error) {
+	mediaCasterType, applicationInstance := s.connection()
+	var segments []string
 	if subFolder != "" {
-		streamFilePath = subFolder + "/" + s.props["name"].(string)
-	} else {
-		streamFilePath = s.props["name"].(string)
+		segments = strings.Split(strings.Trim(subFolder, "/"), "/")
 	}
+	segments = append(segments, s.name, "actions", "connect")
 
-	s.setRestURI(s.baseURI + "/" + streamFilePath + "/actions/connect")
+	restURI := s.subURI(segments...).String()
+	query := url.Values{}
+	query.Set("connectAppName", s.applicationName)
+	query.Set("appInstance", applicationInstance)
+	query.Set("mediaCasterType", mediaCasterType)
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, PUT,
-		"connectAppName="+s.applicationName+"&appInstance="+s.applicationInstance+"&mediaCasterType="+s.mediaCasterType)
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, PUT, query.Encode())
 }
 
 // Disconnect disconnect
 func (s *StreamFile) Disconnect() (map[string]interface{}, error) {
+	return s.DisconnectWithContext(context.Background())
+}
+
+// DisconnectWithContext is like Disconnect but honors ctx for cancellation and deadlines
+func (s *StreamFile) DisconnectWithContext(ctx context.Context) (map[string]interface{}, error) {
 	/*
 	 * curl -X PUT --header 'Accept:application/json; charset=utf-8' --header 'Content-type:application/json; charset=utf-8'
 	 * "http://localhost:8087/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/[YOUR-APP-NAME]/instances/_definst_/incomingstreams/[STREAM-FILE-NAME]/actions/disconnectStream"
@@ -151,19 +203,20 @@ func (s *StreamFile) Disconnect() (map[string]interface{}, error) {
 	 *
 	 * "http:\/\/127.0.0.1:8087\/v2\/servers\/_defaultServer_\/vhosts\/_defaultVHost_\/applications\/live\/instances\/_definst_\/incomingstreams\/bolton_mass\/actions\/disconnectStream"
 	 */
-	s.AddSkipParameter("name")
-	//	s.AddAdditionalParameter("connectAppName", s.applicationName)
-	//	s.AddAdditionalParameter("appInstance", s.applicationInstance)
-	//	s.AddAdditionalParameter("mediaCasterType", s.mediaCasterType)
-
-	baseURI := s.host() + "/servers/" + s.serverInstance() + "/vhosts/" + s.vHostInstance() + "/applications/" + s.applicationName + "/instances/"
-	s.setRestURI(baseURI + s.applicationInstance + "/incomingstreams/" + s.props["name"].(string) + ".stream/actions/disconnectStream")
+	_, applicationInstance := s.connection()
+	restURI := s.vhostURI().join("applications", s.applicationName, "instances", applicationInstance,
+		"incomingstreams", s.name+".stream", "actions", "disconnectStream").String()
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, PUT, "")
 }
 
 // Reset stream
 func (s *StreamFile) Reset() (map[string]interface{}, error) {
+	return s.ResetWithContext(context.Background())
+}
+
+// ResetWithContext is like Reset but honors ctx for cancellation and deadlines
+func (s *StreamFile) ResetWithContext(ctx context.Context) (map[string]interface{}, error) {
 	/*
 	 * curl -X PUT --header 'Accept:application/json; charset=utf-8' --header 'Content-type:application/json; charset=utf-8'
 	 * "http://localhost:8087/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/[YOUR-APP-NAME]/instances/_definst_/incomingstreams/[STREAM-FILE-NAME]/actions/resetStream"
@@ -171,9 +224,9 @@ func (s *StreamFile) Reset() (map[string]interface{}, error) {
 	 *
 	 * "http:\/\/127.0.0.1:8087\/v2\/servers\/_defaultServer_\/vhosts\/_defaultVHost_\/applications\/live\/instances\/_definst_\/incomingstreams\/bolton_mass\/actions\/resetStream"
 	 */
-	s.AddSkipParameter("name")
-	baseURI := s.host() + "/servers/" + s.serverInstance() + "/vhosts/" + s.vHostInstance() + "/applications/" + s.applicationName + "/instances/"
-	s.setRestURI(baseURI + s.applicationInstance + "/incomingstreams/" + s.props["name"].(string) + ".stream/actions/resetStream")
+	_, applicationInstance := s.connection()
+	restURI := s.vhostURI().join("applications", s.applicationName, "instances", applicationInstance,
+		"incomingstreams", s.name+".stream", "actions", "resetStream").String()
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, PUT, "")
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, PUT, "")
 }
@@ -1,6 +1,8 @@
 package wserest
 
 import (
+	"context"
+
 	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
 	"github.com/sebastien4/wse-rest-library-go/entity/base"
 )
@@ -8,14 +10,15 @@ import (
 // StreamTarget is PushPublish map entries utility
 type StreamTarget struct {
 	wowza
+	appName string
 }
 
 // NewStreamTarget create StreamTarget object
 func NewStreamTarget(settings *helper.Settings, appName string) *StreamTarget {
 	s := new(StreamTarget)
 	s.init(settings)
-	s.props["appName"] = appName
-	s.baseURI = s.host() + "/servers/" + s.serverInstance() + "/vhosts/" + s.vHostInstance() + "/applications/" + appName + "/pushpublish/mapentries"
+	s.appName = appName
+	s.baseURI = s.vhostURI().join("applications", appName, "pushpublish", "mapentries").String()
 
 	return s
 }
@@ -30,60 +33,70 @@ func (s *StreamTarget) Create(
 	password,
 	streamName,
 	application string) (map[string]interface{}, error) {
-	s.setRestURI(s.baseURI + "/" + entryName)
+	return s.CreateWithContext(context.Background(), sourceStreamName, entryName, profile, host, userName, password, streamName, application)
+}
+
+// CreateWithContext is like Create but honors ctx for cancellation and deadlines
+func (s *StreamTarget) CreateWithContext(
+	ctx context.Context,
+	sourceStreamName,
+	entryName,
+	profile,
+	host,
+	userName,
+	password,
+	streamName,
+	application string) (map[string]interface{}, error) {
+	props := requestProps(s.subURI(entryName).String())
+	props["appName"] = s.appName
 	if sourceStreamName != "" {
-		s.props["sourceStreamName"] = sourceStreamName
+		props["sourceStreamName"] = sourceStreamName
 	}
 	if entryName != "" {
-		s.props["entryName"] = entryName
+		props["entryName"] = entryName
 	}
 	if profile != "" {
-		s.props["profile"] = profile
+		props["profile"] = profile
 	}
 	if host != "" {
-		s.props["host"] = host
+		props["host"] = host
 	}
 	if userName != "" {
-		s.props["userName"] = userName
+		props["userName"] = userName
 	}
 	if password != "" {
-		s.props["password"] = password
+		props["password"] = password
 	}
 	if streamName != "" {
-		s.props["streamName"] = streamName
+		props["streamName"] = streamName
 	}
 	if application != "" {
-		s.props["application"] = application
+		props["application"] = application
 	}
 
-	response, err := s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, POST, "")
+	response, err := s.sendRequest(ctx, props, []base.Entity{}, POST, "")
 
 	return response, err
 }
 
 // GetAll retrieves the list of PushPublish map entries for the specified Application
 func (s *StreamTarget) GetAll() (map[string]interface{}, error) {
-	s.setNoParams()
-	s.setRestURI(s.baseURI)
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+	return s.GetAllWithContext(context.Background())
 }
 
-func (s *StreamTarget) setNoParams() {
-	s.AddSkipParameter("userName") //todo: correct key name?
-	s.AddSkipParameter("password")
-	s.AddSkipParameter("group")
-	s.AddSkipParameter("sourceStreamName")
-	s.AddSkipParameter("entryName")
-	s.AddSkipParameter("profile")
-	s.AddSkipParameter("host")
-	s.AddSkipParameter("application")
-	s.AddSkipParameter("streamName")
+// GetAllWithContext is like GetAll but honors ctx for cancellation and deadlines
+func (s *StreamTarget) GetAllWithContext(ctx context.Context) (map[string]interface{}, error) {
+	return s.sendRequest(ctx, requestProps(s.baseURI), []base.Entity{}, GET, "")
 }
 
 // Remove deletes the specified PushPublish map entry for the specified Application
 func (s *StreamTarget) Remove(entryName string) (map[string]interface{}, error) {
-	s.setNoParams()
-	s.setRestURI(s.baseURI + "/" + entryName)
+	return s.RemoveWithContext(context.Background(), entryName)
+}
+
+// RemoveWithContext is like Remove but honors ctx for cancellation and deadlines
+func (s *StreamTarget) RemoveWithContext(ctx context.Context, entryName string) (map[string]interface{}, error) {
+	restURI := s.subURI(entryName).String()
 
-	return s.sendRequest(s.preparePropertiesForRequest(), []base.Entity{}, DELETE, "")
+	return s.sendRequest(ctx, requestProps(restURI), []base.Entity{}, DELETE, "")
 }
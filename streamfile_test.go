@@ -16,9 +16,7 @@ func TestStreamfile(t *testing.T) {
 
 	sf := NewStreamFile(settings, "live", "myStream")
 
-	if UseWowza == "" {
-		return
-	}
+	useCassette(t, settings, "streamfile")
 
 	urlProps := map[string]interface{}{
 		"uri":                "rtsp://localhost/vod/mp4:BigBuckBunny_115k.mov",
@@ -64,3 +62,30 @@ func TestStreamfile(t *testing.T) {
 	}
 	t.Log(response)
 }
+
+func TestStreamFileConnectDefaults(t *testing.T) {
+	ts := echoServer(t)
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+
+	sf := NewStreamFile(settings, "live", "camera")
+	response, err := sf.Connect("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "appInstance=_definst_&connectAppName=live&mediaCasterType=rtp"; response["query"] != want {
+		t.Fatalf("expected Connect without Create to use the defaults %q, got %q", want, response["query"])
+	}
+
+	if _, err = sf.Create(nil, "rtsp", "cameras"); err != nil {
+		t.Fatal(err)
+	}
+	if response, err = sf.Connect(""); err != nil {
+		t.Fatal(err)
+	}
+	if want := "appInstance=cameras&connectAppName=live&mediaCasterType=rtsp"; response["query"] != want {
+		t.Fatalf("expected Connect to use the values of Create %q, got %q", want, response["query"])
+	}
+}
@@ -0,0 +1,139 @@
+package wserest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+// newClientCertificate returns a self-signed client certificate and its PEM
+// encoded key pair
+func newClientCertificate(t *testing.T) (*x509.Certificate, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "wse-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+	return cert,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func TestTLSOptions(t *testing.T) {
+	clientCert, certPEM, keyPEM := newClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	ts.StartTLS()
+	defer ts.Close()
+	rootPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+
+	withClientCert := func(o *helper.TLSOptions) *helper.TLSOptions {
+		if err := o.AddClientCertificatePEM(certPEM, keyPEM); err != nil {
+			t.Fatal(err)
+		}
+		return o
+	}
+	for _, tt := range []struct {
+		name    string
+		options *helper.TLSOptions
+		success bool
+	}{
+		{"mutual TLS", withClientCert(&helper.TLSOptions{RootCAsPEM: rootPEM}), true},
+		{"no client certificate", &helper.TLSOptions{RootCAsPEM: rootPEM}, false},
+		{"unknown authority", withClientCert(&helper.TLSOptions{}), false},
+		{"server name", withClientCert(&helper.TLSOptions{RootCAsPEM: rootPEM, ServerName: "example.com"}), true},
+		{"wrong server name", withClientCert(&helper.TLSOptions{RootCAsPEM: rootPEM, ServerName: "wowza.example.org"}), false},
+		{"pinned", withClientCert(&helper.TLSOptions{RootCAsPEM: rootPEM, PinnedSPKIHashes: []string{helper.SPKIHash(ts.Certificate())}}), true},
+		{"wrong pin", withClientCert(&helper.TLSOptions{RootCAsPEM: rootPEM, PinnedSPKIHashes: []string{helper.SPKIHash(clientCert)}}), false},
+		{"pinned without CA", withClientCert(&helper.TLSOptions{InsecureSkipVerify: true, PinnedSPKIHashes: []string{helper.SPKIHash(ts.Certificate())}}), true},
+		{"TLS 1.3", withClientCert(&helper.TLSOptions{RootCAsPEM: rootPEM, MinVersion: tls.VersionTLS13}), true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := helper.NewDefaultSettings()
+			settings.SetHost(ts.URL + "/v2")
+			settings.SetRetryPolicy(nil)
+			if err := settings.SetTLSOptions(tt.options); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewApplication(settings, "live", "", "", "", "").Get()
+			if (err == nil) != tt.success {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	if err := helper.NewDefaultSettings().SetTLSOptions(&helper.TLSOptions{RootCAsPEM: []byte("garbage")}); err == nil {
+		t.Fatal("expected invalid root CAs to be rejected")
+	}
+}
+
+func TestTLSPinnedChain(t *testing.T) {
+	pinned, _, _ := newClientCertificate(t)
+	rogue, rogueCertPEM, rogueKeyPEM := newClientCertificate(t)
+	// the rogue leaf comes with the pinned certificate appended to its chain
+	cert, err := tls.X509KeyPair(rogueCertPEM, rogueKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert.Certificate = append(cert.Certificate, pinned.Raw)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	ts.StartTLS()
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		name    string
+		options *helper.TLSOptions
+		success bool
+	}{
+		{"without CA", &helper.TLSOptions{InsecureSkipVerify: true, PinnedSPKIHashes: []string{helper.SPKIHash(pinned)}}, false},
+		{"rogue pinned", &helper.TLSOptions{InsecureSkipVerify: true, PinnedSPKIHashes: []string{helper.SPKIHash(rogue)}}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := helper.NewDefaultSettings()
+			settings.SetHost(ts.URL + "/v2")
+			settings.SetRetryPolicy(nil)
+			if err := settings.SetTLSOptions(tt.options); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewApplication(settings, "live", "", "", "", "").Get()
+			if (err == nil) != tt.success {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
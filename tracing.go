@@ -0,0 +1,174 @@
+package wserest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go/token"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+type operationKey struct{}
+
+// noopSpan is used when no tracer is configured
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) TraceParent() string                        { return "" }
+func (noopSpan) End(err error)                              {}
+
+// startOperation opens the span of a logical operation, unless ctx is
+// already within one, in which case the requests are traced as its children
+func (w *wowza) startOperation(ctx context.Context, name string) (context.Context, helper.Span) {
+	tracer := w.settings.Tracer()
+	if tracer == nil || ctx.Value(operationKey{}) != nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := tracer.Start(ctx, name)
+	return context.WithValue(ctx, operationKey{}, true), span
+}
+
+// startCall opens the span of the operation req belongs to, named after the
+// resource method sending it, such as "Application.Get"
+func (w *wowza) startCall(ctx context.Context, req *helper.Request) (context.Context, helper.Span) {
+	if w.settings.Tracer() == nil || ctx.Value(operationKey{}) != nil {
+		return ctx, noopSpan{}
+	}
+	return w.startOperation(ctx, operationName(req.Method+" "+resourceOf(req.URI)))
+}
+
+// packagePath is the import path of this package, prefixing the names of its
+// functions in the stack
+var packagePath = reflect.TypeOf(wowza{}).PkgPath()
+
+// operationName returns the name of the outermost exported method of an
+// exported type of this package in the stack, without its WithContext
+// suffix, so that every resource method is traced without naming itself.
+// fallback is returned when there is none.
+func operationName(fallback string) string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	name := fallback
+	for {
+		frame, more := frames.Next()
+		if method, ok := resourceMethod(frame.Function); ok {
+			name = method
+		}
+		if !more {
+			return name
+		}
+	}
+}
+
+// resourceMethod returns "Type.Method" when function is an exported method
+// of an exported type of this package
+func resourceMethod(function string) (string, bool) {
+	rest, ok := strings.CutPrefix(function, packagePath+".(*")
+	if !ok {
+		return "", false
+	}
+	typeName, method, ok := strings.Cut(rest, ").")
+	method, _, _ = strings.Cut(method, ".")
+	if !ok || !token.IsExported(typeName) || !token.IsExported(method) {
+		return "", false
+	}
+	return typeName + "." + strings.TrimSuffix(method, "WithContext"), true
+}
+
+// startSpan opens a span, child of the operation carried by ctx
+func (w *wowza) startSpan(ctx context.Context, name string) (context.Context, helper.Span) {
+	tracer := w.settings.Tracer()
+	if tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return tracer.Start(ctx, name)
+}
+
+var _ helper.Tracer = (*SpanRecorder)(nil)
+
+// RecordedSpan is a span kept in memory by a SpanRecorder
+type RecordedSpan struct {
+	Name       string
+	TraceID    string
+	SpanID     string
+	ParentID   string
+	Attributes map[string]interface{}
+	StartTime  time.Time
+	EndTime    time.Time
+	Err        error
+
+	recorder *SpanRecorder
+}
+
+// SetAttribute records an attribute of the span
+func (s *RecordedSpan) SetAttribute(key string, value interface{}) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+// TraceParent returns the W3C traceparent header of the span
+func (s *RecordedSpan) TraceParent() string {
+	return fmt.Sprintf("00-%s-%s-01", s.TraceID, s.SpanID)
+}
+
+// End records the end of the span
+func (s *RecordedSpan) End(err error) {
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.EndTime = time.Now()
+	s.Err = err
+	s.recorder.ended = append(s.recorder.ended, s)
+}
+
+type recordedSpanKey struct{}
+
+// SpanRecorder is a Tracer keeping the spans in memory, for tests and
+// debugging
+type SpanRecorder struct {
+	mu    sync.Mutex
+	ended []*RecordedSpan
+}
+
+// NewSpanRecorder create SpanRecorder object
+func NewSpanRecorder() *SpanRecorder {
+	return new(SpanRecorder)
+}
+
+// Start opens a span, child of the RecordedSpan carried by ctx if any
+func (r *SpanRecorder) Start(ctx context.Context, name string) (context.Context, helper.Span) {
+	span := &RecordedSpan{
+		Name:       name,
+		SpanID:     randomHex(8),
+		Attributes: make(map[string]interface{}),
+		StartTime:  time.Now(),
+		recorder:   r,
+	}
+	if parent, ok := ctx.Value(recordedSpanKey{}).(*RecordedSpan); ok {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	} else {
+		span.TraceID = randomHex(16)
+	}
+	return context.WithValue(ctx, recordedSpanKey{}, span), span
+}
+
+// Spans returns the ended spans, in the order they ended
+func (r *SpanRecorder) Spans() []*RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*RecordedSpan(nil), r.ended...)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
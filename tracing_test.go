@@ -0,0 +1,97 @@
+package wserest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestTracing(t *testing.T) {
+	ds := &digestServer{algorithm: "MD5", qop: "auth"}
+	var mu sync.Mutex
+	var traceParents []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		traceParents = append(traceParents, r.Header.Get("traceparent"))
+		mu.Unlock()
+		ds.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	settings := newDigestTestSettings(ts.URL)
+	recorder := NewSpanRecorder()
+	settings.SetTracer(recorder)
+
+	if _, err := NewStreamFile(settings, "live", "camera").Create(map[string]interface{}{"uri": "rtsp://camera"}, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Spans()
+	var names []string
+	for _, span := range spans {
+		names = append(names, fmt.Sprintf("%s %v", span.Name, span.Attributes["http.status_code"]))
+	}
+	expected := []string{"HTTP POST 401", "HTTP POST 200", "HTTP PUT 200", "StreamFile.Create <nil>"}
+	if fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Fatalf("expected spans %v, got %v", expected, names)
+	}
+	operation := spans[3]
+	if operation.ParentID != "" {
+		t.Errorf("expected the operation to be a root span")
+	}
+	for i, span := range spans[:3] {
+		if span.TraceID != operation.TraceID || span.ParentID != operation.SpanID {
+			t.Errorf("expected %s to be a child of the operation", span.Name)
+		}
+		if traceParents[i] != span.TraceParent() {
+			t.Errorf("expected traceparent %s, got %s", span.TraceParent(), traceParents[i])
+		}
+	}
+	if spans[0].Attributes["wse.challenge"] != true {
+		t.Errorf("expected the first request to be marked as a challenge")
+	}
+
+	if _, err := NewApplication(settings, "live", "", "", "", "").Get(); err != nil {
+		t.Fatal(err)
+	}
+	spans = recorder.Spans()[4:]
+	if len(spans) != 2 || spans[1].Name != "Application.Get" || spans[0].ParentID != spans[1].SpanID {
+		t.Fatalf("expected an operation span around the request, got %v", spans)
+	}
+}
+
+func TestTracingOperationNames(t *testing.T) {
+	ts := echoServer(t)
+	defer ts.Close()
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	recorder := NewSpanRecorder()
+	settings.SetTracer(recorder)
+
+	publisher := NewPublisher(settings, "encoder")
+	if _, err := publisher.CreateWithContext(context.Background(), "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := publisher.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStatistics(settings).GetApplicationStatistics(NewApplication(settings, "live", "", "", "", "")); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, span := range recorder.Spans() {
+		if span.ParentID == "" {
+			names = append(names, span.Name)
+		}
+	}
+	expected := []string{"Publisher.Create", "Publisher.Remove", "Statistics.GetApplicationStatistics"}
+	if fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Fatalf("expected operations %v, got %v", expected, names)
+	}
+}
@@ -0,0 +1,66 @@
+package wserest
+
+import (
+	"net/url"
+	"strings"
+)
+
+// uri builds a REST URI: each path segment is escaped on its own and the
+// query is encoded with net/url. Every method returns a copy, so a uri can
+// be extended in several directions.
+type uri struct {
+	path  string
+	query url.Values
+}
+
+// newURI returns a uri rooted at base, an already escaped URL such as the
+// host of helper.Settings or the baseURI of a resource
+func newURI(base string) uri {
+	return uri{path: strings.TrimSuffix(base, "/")}
+}
+
+// join appends segments to the path, a segment holding a slash, a space or
+// a question mark stays a single segment
+func (u uri) join(segments ...string) uri {
+	var b strings.Builder
+	b.WriteString(u.path)
+	for _, segment := range segments {
+		b.WriteByte('/')
+		b.WriteString(url.PathEscape(segment))
+	}
+	u.path = b.String()
+	return u
+}
+
+// with adds the query parameter key set to value
+func (u uri) with(key, value string) uri {
+	query := make(url.Values, len(u.query)+1)
+	for k, v := range u.query {
+		query[k] = append([]string(nil), v...)
+	}
+	query.Add(key, value)
+	u.query = query
+	return u
+}
+
+func (u uri) String() string {
+	if len(u.query) == 0 {
+		return u.path
+	}
+	return u.path + "?" + u.query.Encode()
+}
+
+// serverURI returns the URI of the server instance
+func (w *wowza) serverURI() uri {
+	return newURI(w.host()).join("servers", w.serverInstance())
+}
+
+// vhostURI returns the URI of the virtual host instance
+func (w *wowza) vhostURI() uri {
+	return w.serverURI().join("vhosts", w.vHostInstance())
+}
+
+// subURI returns the URI of the resource extended with segments
+func (w *wowza) subURI(segments ...string) uri {
+	return newURI(w.baseURI).join(segments...)
+}
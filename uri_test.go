@@ -0,0 +1,64 @@
+package wserest
+
+import (
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestURI(t *testing.T) {
+	base := newURI("http://localhost:8087/v2/")
+	tests := []struct {
+		got  uri
+		want string
+	}{
+		{base.join("servers", "_defaultServer_"), "http://localhost:8087/v2/servers/_defaultServer_"},
+		{base.join("streamfiles", "my cam/1?x=y"), "http://localhost:8087/v2/streamfiles/my%20cam%2F1%3Fx=y"},
+		{base.join("logfiles").with("search", "a&b c").with("search", "d"), "http://localhost:8087/v2/logfiles?search=a%26b+c&search=d"},
+		{newURI("http://h/v2/users/a%2Fb").join("adv"), "http://h/v2/users/a%2Fb/adv"},
+	}
+	for _, tt := range tests {
+		if got := tt.got.String(); got != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+	}
+
+	// with returns a copy, the original query is left untouched
+	q := base.with("a", "1")
+	q.with("b", "2")
+	if got := q.String(); got != "http://localhost:8087/v2?a=1" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestResourceURIs(t *testing.T) {
+	dryRun := NewDryRun()
+	settings := helper.NewDefaultSettings()
+	settings.SetHost("http://wse.test:8087/v2")
+	settings.SetDryRun(dryRun)
+
+	if _, err := NewStatistics(settings).GetServerStatisticsCurrent(NewServer(settings)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewApplication(settings, "live 2", "", "", "", "").GetAdvanced(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStreamFile(settings, "live", "cam/1").Reset(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"http://wse.test:8087/v2/machine/monitoring/current",
+		"http://wse.test:8087/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/live%202/adv",
+		"http://wse.test:8087/v2/servers/_defaultServer_/vhosts/_defaultVHost_/applications/live/instances/_definst_/incomingstreams/cam%2F1.stream/actions/resetStream",
+	}
+	requests := dryRun.Requests()
+	if len(requests) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(requests), len(want))
+	}
+	for i, r := range requests {
+		if r.URI != want[i] {
+			t.Errorf("got %s, want %s", r.URI, want[i])
+		}
+	}
+}
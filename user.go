@@ -1,6 +1,8 @@
 package wserest
 
 import (
+	"context"
+
 	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
 	"github.com/sebastien4/wse-rest-library-go/entity/base"
 )
@@ -8,42 +10,53 @@ import (
 // User is Server Users utiliyt
 type User struct {
 	wowza
+	userName string
 }
 
 // NewUser create User object
 func NewUser(settings *helper.Settings, userName string) *User {
 	u := new(User)
 	u.init(settings)
-	u.props["userName"] = userName
-	u.props["password"] = ""
-	u.props["groups"] = []string{}
-	u.baseURI = u.host() + "/servers/" + u.serverInstance() + "/users"
+	u.userName = userName
+	u.baseURI = u.serverURI().join("users").String()
 	return u
 }
 
 // Create adds a new server User to the list
 func (u *User) Create(password string, group []string) (map[string]interface{}, error) {
-	u.props["password"] = password
-	u.props["group"] = group
-	u.setRestURI(u.baseURI)
-	response, err := u.sendRequest(u.preparePropertiesForRequest(), []base.Entity{}, POST, "")
+	return u.CreateWithContext(context.Background(), password, group)
+}
+
+// CreateWithContext is like Create but honors ctx for cancellation and deadlines
+func (u *User) CreateWithContext(ctx context.Context, password string, group []string) (map[string]interface{}, error) {
+	props := requestProps(u.baseURI)
+	props["userName"] = u.userName
+	props["password"] = password
+	props["groups"] = []string{}
+	props["group"] = group
+	response, err := u.sendRequest(ctx, props, []base.Entity{}, POST, "")
 
 	return response, err
 }
 
 // GetAll retrieves the list of server Users
 func (u *User) GetAll() (map[string]interface{}, error) {
-	u.AddSkipParameter("userName")
-	u.AddSkipParameter("password")
-	u.AddSkipParameter("group")
-	u.setRestURI(u.baseURI)
+	return u.GetAllWithContext(context.Background())
+}
 
-	return u.sendRequest(u.preparePropertiesForRequest(), []base.Entity{}, GET, "")
+// GetAllWithContext is like GetAll but honors ctx for cancellation and deadlines
+func (u *User) GetAllWithContext(ctx context.Context) (map[string]interface{}, error) {
+	return u.sendRequest(ctx, requestProps(u.baseURI), []base.Entity{}, GET, "")
 }
 
 // Remove deletes the specified User configuration
 func (u *User) Remove() (map[string]interface{}, error) {
-	u.setRestURI(u.baseURI + "/" + u.props["userName"].(string))
+	return u.RemoveWithContext(context.Background())
+}
+
+// RemoveWithContext is like Remove but honors ctx for cancellation and deadlines
+func (u *User) RemoveWithContext(ctx context.Context) (map[string]interface{}, error) {
+	restURI := u.subURI(u.userName).String()
 
-	return u.sendRequest(u.preparePropertiesForRequest(), []base.Entity{}, DELETE, "")
+	return u.sendRequest(ctx, requestProps(restURI), []base.Entity{}, DELETE, "")
 }
@@ -16,9 +16,7 @@ func TestUser(t *testing.T) {
 
 	sf := NewUser(settings, "newuser3")
 
-	if UseWowza == "" {
-		return
-	}
+	useCassette(t, settings, "user")
 
 	response, err := sf.Create("newpass4", []string{"admin"})
 	if err != nil {
@@ -2,15 +2,21 @@ package wserest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
 	"github.com/sebastien4/wse-rest-library-go/entity/base"
+	"github.com/sebastien4/wse-rest-library-go/redact"
 )
 
 // VerbType is verb type
@@ -42,27 +48,79 @@ func (v VerbType) String() string {
 	}
 }
 
+// wowza is embedded by every resource type. It is never modified once the
+// resource is created: each request builds its own properties, so a resource
+// can be shared between goroutines. The deprecated parameters are the only
+// exception, they are guarded by their own lock.
 type wowza struct {
-	skip       map[string]interface{}
+	settings *helper.Settings
+	baseURI  string
+	params   *pendingParams
+	// feature is the part of the REST API the resource needs, nil when it is
+	// available on every server version
+	feature *feature
+}
+
+// pendingParams holds the parameters set by AddSkipParameter and
+// AddAdditionalParameter until the next request
+type pendingParams struct {
+	mu         sync.Mutex
+	skip       map[string]bool
 	additional map[string]interface{}
-	settings   *helper.Settings
-	props      map[string]interface{}
-	baseURI    string
 }
 
 func (w *wowza) init(settings *helper.Settings) {
 	w.settings = settings
-	w.skip = make(map[string]interface{})
-	w.additional = make(map[string]interface{})
-	w.props = make(map[string]interface{})
+	w.params = new(pendingParams)
 }
 
-/*func (w *wowza) restURI() string {
-	return w.props["restURI"].(string)
-}*/
+// AddAdditionalParameter adds key to the body of the next request
+//
+// Deprecated: the parameter is shared by every goroutine using the resource
+// and consumed by whichever request comes next. Use the entity setters or a
+// Middleware editing the request body instead.
+func (w *wowza) AddAdditionalParameter(key string, value interface{}) {
+	w.params.mu.Lock()
+	defer w.params.mu.Unlock()
+	if w.params.additional == nil {
+		w.params.additional = make(map[string]interface{})
+	}
+	w.params.additional[key] = value
+}
+
+// AddSkipParameter leaves key out of the body of the next request
+//
+// Deprecated: the parameter is shared by every goroutine using the resource
+// and consumed by whichever request comes next. Use a Middleware editing the
+// request body instead.
+func (w *wowza) AddSkipParameter(key string) {
+	w.params.mu.Lock()
+	defer w.params.mu.Unlock()
+	if w.params.skip == nil {
+		w.params.skip = make(map[string]bool)
+	}
+	w.params.skip[key] = true
+}
+
+// applyParams applies the pending deprecated parameters to props and forgets
+// them
+func (w *wowza) applyParams(props map[string]interface{}) {
+	w.params.mu.Lock()
+	skip, additional := w.params.skip, w.params.additional
+	w.params.skip, w.params.additional = nil, nil
+	w.params.mu.Unlock()
+	for k := range skip {
+		delete(props, k)
+	}
+	for k, v := range additional {
+		props[k] = v
+	}
+}
 
-func (w *wowza) setRestURI(uri string) {
-	w.props["restURI"] = uri
+// requestProps returns the properties of a request sent to restURI, the
+// REST API expects the URI to be repeated in the body
+func requestProps(restURI string) map[string]interface{} {
+	return map[string]interface{}{"restURI": restURI}
 }
 
 func (w *wowza) host() string {
@@ -92,19 +150,41 @@ func (w *wowza) getEntities(args []base.Entity, baseURI string) []base.Entity {
 	return entities
 }
 
-func (w *wowza) debug(v ...interface{}) {
-	if w.settings.IsDebug() {
-		println(fmt.Sprint(v...))
+type requestTimeoutKey struct{}
+
+// WithRequestTimeout returns a copy of ctx whose HTTP requests use timeout
+// instead of the one configured in helper.Settings. Unlike a deadline on ctx,
+// which bounds a whole operation, it applies to each request separately.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, requestTimeoutKey{}, timeout)
+}
+
+func (w *wowza) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := w.requestTimeout(ctx)
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// requestTimeout returns the timeout of each HTTP request sent with ctx
+func (w *wowza) requestTimeout(ctx context.Context) time.Duration {
+	if t, ok := ctx.Value(requestTimeoutKey{}).(time.Duration); ok {
+		return t
 	}
+	return w.settings.Timeout()
 }
 
-func (w *wowza) debugf(format string, v ...interface{}) {
-	if w.settings.IsDebug() {
-		println(fmt.Sprintf(format, v...))
+func (w *wowza) sendRequest(ctx context.Context, props map[string]interface{}, entities []base.Entity, verbType VerbType, queryParams string) (map[string]interface{}, error) {
+	contents := make(map[string]interface{})
+	if err := w.sendRequestSeb(ctx, &contents, props, entities, verbType, queryParams); err != nil {
+		return nil, err
 	}
+	return contents, nil
 }
 
-func (w *wowza) sendRequest(props map[string]interface{}, entities []base.Entity, verbType VerbType, queryParams string) (map[string]interface{}, error) {
+func (w *wowza) sendRequestSeb(ctx context.Context, itf interface{}, props map[string]interface{}, entities []base.Entity, verbType VerbType, queryParams string) error {
+	w.applyParams(props)
 	if restURI, ok := props["restURI"].(string); ok {
 		for _, entity := range entities {
 			name := entity.EntityName()
@@ -112,156 +192,294 @@ func (w *wowza) sendRequest(props map[string]interface{}, entities []base.Entity
 		}
 		jsonb, err := json.Marshal(props)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		if queryParams != "" {
 			restURI += "?" + queryParams
 		}
-		w.debugf("JSON REQUEST to %s with verb %s: %+v", restURI, verbType, props)
+		req := &helper.Request{
+			Method: verbType.String(),
+			URI:    restURI,
+			Header: make(http.Header),
+			Body:   jsonb,
+		}
+		_, err = w.call(ctx, req, itf)
+		return err
+	}
+	return errors.New("no restURI")
+}
 
-		client := &http.Client{Timeout: 10 * time.Second}
+// call sends req through the middlewares and decodes the response into itf
+func (w *wowza) call(ctx context.Context, req *helper.Request, itf interface{}) (*helper.Response, error) {
+	if err := w.checkFeature(nil); err != nil {
+		return nil, err
+	}
+	handler := w.handler(itf)
+	middlewares := w.settings.Middlewares()
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	ctx, span := w.startCall(ctx, req)
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	if err != nil {
+		err = w.checkFeature(err)
+	}
+	w.logCall(ctx, req, resp, time.Since(start), err)
+	span.End(err)
+	return resp, err
+}
 
-		req, err := http.NewRequest(verbType.String(), restURI, bytes.NewReader(jsonb))
-		req.Header.Add("Accept", "application/json; charset=utf-8")
-		req.Header.Add("Content-type", "application/json; charset=utf-8")
-		req.Header.Add("Content-Length", strconv.Itoa(len(jsonb)))
-		resp, err := client.Do(req)
+func (w *wowza) authenticator() helper.Authenticator {
+	if a := w.settings.Authenticator(); a != nil {
+		return a
+	}
+	if w.settings.IsUseDigest() {
+		return newDigestAuthenticator(w.settings.Username(), w.settings.Password(), sharedDigestCache)
+	}
+	return NoAuthenticator{}
+}
+
+// handler returns the innermost Handler, sending the request and decoding
+// the response into itf
+func (w *wowza) handler(itf interface{}) helper.Handler {
+	return func(ctx context.Context, req *helper.Request) (*helper.Response, error) {
+		resp, err := w.sendCached(ctx, req)
 		if err != nil {
 			return nil, err
 		}
-		if w.settings.IsUseDigest() && resp.StatusCode == http.StatusUnauthorized {
-			resp.Body.Close()
-			var (
-				auth     *authorization
-				wa       *wwwAuthenticate
-				waString string
-			)
-			if waString = resp.Header.Get("WWW-Authenticate"); waString == "" {
-				return nil, fmt.Errorf("failed to get WWW-Authenticate header, please check your server configuration")
-			}
-			wa = newWwwAuthenticate(waString)
-			dr := new(digestRequest)
-			dr.UpdateRequest(w.settings.Username(), w.settings.Password(), verbType.String(), restURI, string(jsonb))
-			dr.CertVal = true
-			dr.Wa = wa
-			if auth, err = newAuthorization(dr); err != nil {
-				return nil, err
-			}
-			req, err = http.NewRequest(verbType.String(), restURI, bytes.NewReader(jsonb))
-			req.Header.Add("Accept", "application/json; charset=utf-8")
-			req.Header.Add("Content-type", "application/json; charset=utf-8")
-			req.Header.Add("Content-Length", strconv.Itoa(len(jsonb)))
-			req.Header.Add("Authorization", auth.toString())
-			resp, err = client.Do(req)
-			if err != nil {
-				return nil, err
+		w.observeServerVersion(resp)
+		if err = checkResponse(req.Method, req.URI, resp.StatusCode, resp.Body); err != nil {
+			return resp, err
+		}
+		if len(bytes.TrimSpace(resp.Body)) > 0 {
+			if err = json.Unmarshal(resp.Body, itf); err != nil {
+				return resp, fmt.Errorf("failed to decode response of %s %s: %w", req.Method, req.URI, err)
 			}
 		}
-		defer resp.Body.Close()
-		contents := make(map[string]interface{})
-		json.NewDecoder(resp.Body).Decode(&contents)
-
-		w.debugf("RETURN: %+v", contents)
-
-		w.skip = make(map[string]interface{})
-		w.additional = make(map[string]interface{})
+		resp.Payload = itf
+		return resp, nil
+	}
+}
 
-		return contents, nil
+// logCall logs a call once it is complete, failures at warning level
+func (w *wowza) logCall(ctx context.Context, req *helper.Request, resp *helper.Response, duration time.Duration, err error) {
+	l := w.logger()
+	if l == nil {
+		return
 	}
-	return nil, errors.New("no restURI")
+	attrs := []any{"verb", req.Method, "uri", req.URI, "duration", duration, "request", redact.Body(req.Body)}
+	if resp != nil {
+		attrs = append(attrs, "status", resp.StatusCode, "response", redact.Body(resp.Body))
+	}
+	if err != nil {
+		l.WarnContext(ctx, "wse request failed", append(attrs, "error", err)...)
+		return
+	}
+	l.DebugContext(ctx, "wse request", attrs...)
 }
 
-func (w *wowza) sendRequestSeb(itf interface{}, props map[string]interface{}, entities []base.Entity, verbType VerbType, queryParams string) error {
-	if restURI, ok := props["restURI"].(string); ok {
-		for _, entity := range entities {
-			name := entity.EntityName()
-			props[name] = entity
-		}
-		jsonb, err := json.Marshal(props)
-		if err != nil {
-			return err
-		}
+// send sends the request, retrying transient failures according to the
+// retry policy, and returns the last response
+func (w *wowza) send(ctx context.Context, req *helper.Request) (*helper.Response, error) {
+	policy := w.settings.RetryPolicy()
 
-		if queryParams != "" {
-			restURI += "?" + queryParams
+	for retry := 1; ; retry++ {
+		resp, retryAfter, err := w.attemptEndpoints(ctx, req)
+		if !policy.Allows(req.Method) || retry >= policy.MaxAttempts || !isTransient(ctx, policy, resp, err) {
+			return resp, err
 		}
-		w.debugf("JSON REQUEST to %s with verb %s: %+v", restURI, verbType, props)
 
-		client := &http.Client{Timeout: 10 * time.Second}
-
-		req, err := http.NewRequest(verbType.String(), restURI, bytes.NewReader(jsonb))
-		req.Header.Add("Accept", "application/json; charset=utf-8")
-		req.Header.Add("Content-type", "application/json; charset=utf-8")
-		req.Header.Add("Content-Length", strconv.Itoa(len(jsonb)))
-		resp, err := client.Do(req)
-		if err != nil {
-			return err
-		}
-		if w.settings.IsUseDigest() && resp.StatusCode == http.StatusUnauthorized {
-			resp.Body.Close()
-			var (
-				auth     *authorization
-				wa       *wwwAuthenticate
-				waString string
-			)
-			if waString = resp.Header.Get("WWW-Authenticate"); waString == "" {
-				return fmt.Errorf("failed to get WWW-Authenticate header, please check your server configuration")
-			}
-			wa = newWwwAuthenticate(waString)
-			dr := new(digestRequest)
-			dr.UpdateRequest(w.settings.Username(), w.settings.Password(), verbType.String(), restURI, string(jsonb))
-			dr.CertVal = true
-			dr.Wa = wa
-			if auth, err = newAuthorization(dr); err != nil {
-				return err
+		// a Retry-After longer than the backoff is honored, up to the
+		// maximum backoff, the context bounds the wait in any case
+		delay := policy.Backoff(retry)
+		if retryAfter > delay {
+			delay = retryAfter
+			if policy.MaxBackoff > 0 && delay > policy.MaxBackoff {
+				delay = policy.MaxBackoff
 			}
-			req, err = http.NewRequest(verbType.String(), restURI, bytes.NewReader(jsonb))
-			req.Header.Add("Accept", "application/json; charset=utf-8")
-			req.Header.Add("Content-type", "application/json; charset=utf-8")
-			req.Header.Add("Content-Length", strconv.Itoa(len(jsonb)))
-			req.Header.Add("Authorization", auth.toString())
-			resp, err = client.Do(req)
+		}
+		if l := w.logger(); l != nil {
+			attrs := []any{"verb", req.Method, "uri", req.URI, "attempt", retry, "delay", delay}
 			if err != nil {
-				return err
+				attrs = append(attrs, "error", err)
+			} else {
+				attrs = append(attrs, "status", resp.StatusCode)
 			}
+			l.InfoContext(ctx, "retrying wse request", attrs...)
 		}
-		defer resp.Body.Close()
-		err = json.NewDecoder(resp.Body).Decode(itf)
-		if err != nil {
-			return err
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
 		}
+	}
+}
+
+// attempt sends the request once, unless the circuit breaker of its host is
+// open
+func (w *wowza) attempt(ctx context.Context, req *helper.Request) (*helper.Response, time.Duration, error) {
+	breaker := w.breaker(req.URI)
+	if err := breaker.allow(hostOf(req.URI)); err != nil {
+		return nil, 0, err
+	}
+	resp, retryAfter, err := w.attemptOnce(ctx, req)
+	if ctx.Err() != nil {
+		// canceled by the caller, this says nothing about the host
+		breaker.abort()
+	} else {
+		breaker.record(isHostFailure(resp, err))
+	}
+	return resp, retryAfter, err
+}
 
-		w.debugf("RETURN: %+v", itf)
+// attemptOnce sends the request within its own timeout and the limits of
+// its host
+func (w *wowza) attemptOnce(ctx context.Context, req *helper.Request) (*helper.Response, time.Duration, error) {
+	limiter := w.limiter(req.URI)
+	if err := limiter.wait(ctx); err != nil {
+		return nil, 0, err
+	}
+	release, err := limiter.acquire(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer release()
 
-		w.skip = make(map[string]interface{})
-		w.additional = make(map[string]interface{})
+	ctx, cancel := w.requestContext(ctx)
+	defer cancel()
 
-		return nil
+	resp, err := w.do(ctx, req)
+	if err != nil {
+		return nil, 0, err
 	}
-	return errors.New("no restURI")
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &helper.Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}, retryAfter(resp.Header.Get("Retry-After")), nil
 }
 
-func (w *wowza) AddAdditionalParameter(key string, value interface{}) {
-	w.additional[key] = value
+// isTransient reports whether a request that got resp or failed with err is
+// worth sending again
+func isTransient(ctx context.Context, policy *helper.RetryPolicy, resp *helper.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return isNetworkError(err)
+	}
+	return policy.IsRetryableStatus(resp.StatusCode)
 }
 
-func (w *wowza) AddSkipParameter(key string) {
-	w.skip[key] = true
+// isNetworkError reports whether err comes from the connection to the
+// server, timeouts included
+func isNetworkError(err error) bool {
+	// *url.Error implements net.Error whatever the RoundTripper failed with
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an
+// HTTP date
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
 }
 
-func (w *wowza) preparePropertiesForRequest() map[string]interface{} {
-	newProps := make(map[string]interface{})
-	for k, v := range w.props {
-		if _, ok := w.skip[k]; ok {
-			continue
+// do sends the request, answering the authentication challenges of the server
+func (w *wowza) do(ctx context.Context, r *helper.Request) (*http.Response, error) {
+	client := w.settings.HTTPClient()
+	if dryRun := w.settings.DryRun(); dryRun != nil {
+		client = &http.Client{Transport: dryRun}
+	}
+	auth := w.authenticator()
+
+	for challenges := 0; ; challenges++ {
+		resp, retry, err := w.roundTrip(ctx, client, auth, r, challenges < maxChallenges)
+		if err != nil || !retry {
+			return resp, err
 		}
-		newProps[k] = v
+	}
+}
+
+// roundTrip sends the request once within its own span, when answer is set
+// a 401 challenge is handled and retry reports whether to send it again
+func (w *wowza) roundTrip(ctx context.Context, client *http.Client, auth helper.Authenticator, r *helper.Request, answer bool) (resp *http.Response, retry bool, err error) {
+	ctx, span := w.startSpan(ctx, "HTTP "+r.Method)
+	defer func() { span.End(err) }()
+	span.SetAttribute("http.method", r.Method)
+	span.SetAttribute("http.url", r.URI)
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URI, bytes.NewReader(r.Body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Add("Accept", "application/json; charset=utf-8")
+	req.Header.Add("Content-type", "application/json; charset=utf-8")
+	req.Header.Add("Content-Length", strconv.Itoa(len(r.Body)))
+	for name, values := range r.Header {
+		req.Header[name] = append([]string(nil), values...)
+	}
+	if traceParent := span.TraceParent(); traceParent != "" {
+		req.Header.Set("traceparent", traceParent)
+	}
+	if req, err = authorize(auth, req, r.Body); err != nil {
+		return nil, false, err
 	}
 
-	for k, v := range w.additional {
-		newProps[k] = v
+	start := time.Now()
+	resp, err = client.Do(req)
+	if l := w.logger(); l != nil {
+		attrs := []any{"verb", req.Method, "uri", r.URI, "duration", time.Since(start), "header", redact.RequestHeader(req)}
+		if err != nil {
+			attrs = append(attrs, "error", err)
+		} else {
+			attrs = append(attrs, "status", resp.StatusCode)
+		}
+		l.DebugContext(ctx, "wse http exchange", attrs...)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	span.SetAttribute("http.status_code", resp.StatusCode)
+	if resp.StatusCode != http.StatusUnauthorized || !answer {
+		return resp, false, nil
 	}
 
-	return newProps
+	retry, err = auth.Challenge(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, false, err
+	}
+	if !retry {
+		return resp, false, nil
+	}
+	span.SetAttribute("wse.challenge", true)
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil, true, nil
 }
@@ -0,0 +1,114 @@
+package wserest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+)
+
+func TestContextCancellation(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := NewApplication(settings, "live", "", "", "", "").GetWithContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+	settings.SetTimeout(time.Hour)
+
+	ctx := WithRequestTimeout(context.Background(), 50*time.Millisecond)
+	_, err := NewApplication(settings, "live", "", "", "", "").GetWithContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestSharedClient(t *testing.T) {
+	var calls int
+	settings := helper.NewDefaultSettings()
+	settings.SetTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(`{}`)),
+			Request:    r,
+		}, nil
+	}))
+
+	if _, err := NewApplication(settings, "live", "", "", "", "").Get(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStatistics(settings).GetServerStatistics(NewServer(settings)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected both resources to use the configured transport, got %d calls", calls)
+	}
+	if settings.HTTPClient() != settings.HTTPClient() {
+		t.Fatal("expected the client to be shared")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestDeprecatedParameters(t *testing.T) {
+	ts := echoServer(t)
+	defer ts.Close()
+
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(ts.URL + "/v2")
+
+	app := NewApplication(settings, "live", "Live", "", "", "")
+	app.AddSkipParameter("description")
+	app.AddAdditionalParameter("appInstance", "_definst_")
+	got, err := app.Create(nil, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := got["body"].(map[string]interface{})
+	if _, ok := body["description"]; ok || body["appInstance"] != "_definst_" || body["appType"] != "Live" {
+		t.Fatalf("expected the parameters to edit the body, got %v", body)
+	}
+
+	if got, err = app.Create(nil, nil, nil, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	body = got["body"].(map[string]interface{})
+	if _, ok := body["description"]; !ok || body["appInstance"] != nil {
+		t.Fatalf("expected the parameters to apply to one request only, got %v", body)
+	}
+}
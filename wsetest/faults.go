@@ -0,0 +1,233 @@
+package wsetest
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Fault describes a misbehavior injected into the requests it matches
+type Fault struct {
+	// Method restricts the fault to a verb, any when empty
+	Method string
+	// Path is matched against the end of the request path, segment by
+	// segment, with the syntax of path.Match in each segment, for instance
+	// "/streamrecorders/*/actions/stopRecording". Any path matches when
+	// empty.
+	Path string
+	// Probability is the chance that a matching request is affected, it
+	// always is when 0
+	Probability float64
+	// Times bounds how many requests are affected, unlimited when 0
+	Times int
+
+	// Latency delays the request
+	Latency time.Duration
+	// Disconnect drops the connection instead of answering
+	Disconnect bool
+	// Status replaces the response with one with this status code
+	Status int
+	// Header is sent with the replaced response
+	Header http.Header
+	// Body is the body of the replaced response, such as a truncated JSON
+	// document or {"success":false}
+	Body string
+}
+
+// matches reports whether the fault applies to a request
+func (f *Fault) matches(method, requestPath string) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, method) {
+		return false
+	}
+	if f.Path == "" {
+		return true
+	}
+	pattern := strings.Split(strings.Trim(f.Path, "/"), "/")
+	segments := strings.Split(strings.Trim(requestPath, "/"), "/")
+	if len(pattern) > len(segments) {
+		return false
+	}
+	segments = segments[len(segments)-len(pattern):]
+	for i, p := range pattern {
+		if ok, err := path.Match(p, segments[i]); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// replaces reports whether the fault answers instead of the server
+func (f *Fault) replaces() bool {
+	return f.Disconnect || f.Status != 0 || f.Body != ""
+}
+
+// FaultInjector injects faults into requests, either on the client side as
+// a RoundTripper or on the server side as an http.Handler. The first fault
+// matching a request is applied.
+type FaultInjector struct {
+	mu       sync.Mutex
+	faults   []*Fault
+	applied  []int
+	rand     *rand.Rand
+	injected int
+}
+
+// NewFaultInjector creates a FaultInjector applying faults
+func NewFaultInjector(faults ...Fault) *FaultInjector {
+	f := &FaultInjector{
+		applied: make([]int, len(faults)),
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for i := range faults {
+		f.faults = append(f.faults, &faults[i])
+	}
+	return f
+}
+
+// SetSeed makes the probabilistic faults reproducible
+func (f *FaultInjector) SetSeed(seed int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rand = rand.New(rand.NewSource(seed))
+}
+
+// Injected returns how many requests were affected
+func (f *FaultInjector) Injected() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.injected
+}
+
+// pick returns the fault to apply to a request, nil when none
+func (f *FaultInjector) pick(method, requestPath string) *Fault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, fault := range f.faults {
+		if !fault.matches(method, requestPath) {
+			continue
+		}
+		if fault.Times > 0 && f.applied[i] >= fault.Times {
+			continue
+		}
+		if fault.Probability > 0 && f.rand.Float64() >= fault.Probability {
+			continue
+		}
+		f.applied[i]++
+		f.injected++
+		return fault
+	}
+	return nil
+}
+
+// Transport wraps next, http.DefaultTransport when nil, with the faults
+func (f *FaultInjector) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		fault := f.pick(req.Method, req.URL.Path)
+		if fault == nil {
+			return next.RoundTrip(req)
+		}
+		if fault.Latency > 0 {
+			timer := time.NewTimer(fault.Latency)
+			select {
+			case <-timer.C:
+			case <-req.Context().Done():
+				timer.Stop()
+				return nil, req.Context().Err()
+			}
+		}
+		if !fault.replaces() {
+			return next.RoundTrip(req)
+		}
+		if req.Body != nil {
+			io.Copy(io.Discard, req.Body)
+			req.Body.Close()
+		}
+		if fault.Disconnect {
+			return nil, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+		}
+		status := fault.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		header := fault.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode:    status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(fault.Body)),
+			ContentLength: int64(len(fault.Body)),
+			Request:       req,
+		}, nil
+	})
+}
+
+// Handler wraps next with the faults
+func (f *FaultInjector) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fault := f.pick(r.Method, r.URL.Path)
+		if fault == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if fault.Latency > 0 {
+			timer := time.NewTimer(fault.Latency)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+		if !fault.replaces() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if fault.Disconnect {
+			if hj, ok := w.(http.Hijacker); ok {
+				if conn, _, err := hj.Hijack(); err == nil {
+					conn.Close()
+					return
+				}
+			}
+			panic(http.ErrAbortHandler)
+		}
+		for name, values := range fault.Header {
+			w.Header()[name] = values
+		}
+		status := fault.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, fault.Body)
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithFaults injects faults into the requests received by the server
+func WithFaults(injector *FaultInjector) Option {
+	return func(s *Server) {
+		s.faults = injector
+	}
+}
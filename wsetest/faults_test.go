@@ -0,0 +1,114 @@
+package wsetest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	wserest "github.com/sebastien4/wse-rest-library-go"
+	"github.com/sebastien4/wse-rest-library-go/wsetest"
+)
+
+func TestFaultInjectorHandler(t *testing.T) {
+	faults := wsetest.NewFaultInjector(
+		wsetest.Fault{Method: "GET", Path: "/applications/flaky", Disconnect: true, Times: 1},
+		wsetest.Fault{Path: "/applications/truncated", Body: `{"success":tr`},
+		wsetest.Fault{Path: "/applications/refused", Body: `{"success":false,"message":"refused"}`},
+		wsetest.Fault{Path: "/applications/slow", Latency: 200 * time.Millisecond},
+		wsetest.Fault{Path: "/streamrecorders/*/actions/stopRecording", Status: http.StatusServiceUnavailable},
+	)
+	srv := wsetest.NewServer(wsetest.WithFaults(faults))
+	defer srv.Close()
+	for _, name := range []string{"flaky", "truncated", "refused", "slow", "live"} {
+		srv.AddApplication(name, "Live")
+	}
+	settings := srv.Settings()
+	settings.RetryPolicy().InitialBackoff = time.Millisecond
+
+	if _, err := wserest.NewApplication(settings, "flaky", "", "", "", "").Get(); err != nil {
+		t.Fatalf("expected the reset connection to be retried, got %v", err)
+	}
+	var apiErr *wserest.APIError
+	if _, err := wserest.NewApplication(settings, "truncated", "", "", "", "").Get(); err == nil || errors.As(err, &apiErr) {
+		t.Fatalf("expected a decoding error, got %v", err)
+	}
+	if _, err := wserest.NewApplication(settings, "refused", "", "", "", "").Get(); !errors.As(err, &apiErr) || apiErr.Message != "refused" {
+		t.Fatalf("expected success:false to be reported, got %v", err)
+	}
+	ctx := wserest.WithRequestTimeout(context.Background(), 50*time.Millisecond)
+	settings.SetRetryPolicy(nil)
+	if _, err := wserest.NewApplication(settings, "slow", "", "", "", "").GetWithContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+	recording := wserest.NewRecording(settings, "live", "")
+	if _, err := recording.Stop("camera"); wserest.StatusCode(err) != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %v", err)
+	}
+	if _, err := recording.Split("camera"); !wserest.IsNotFound(err) {
+		t.Fatalf("expected other actions to reach the server, got %v", err)
+	}
+	if faults.Injected() != 5 {
+		t.Fatalf("expected 5 faults to be injected, got %d", faults.Injected())
+	}
+}
+
+func TestFaultInjectorTransport(t *testing.T) {
+	srv := wsetest.NewServer()
+	defer srv.Close()
+	srv.AddApplication("live", "Live")
+
+	faults := wsetest.NewFaultInjector(wsetest.Fault{
+		Status: http.StatusUnauthorized,
+		Header: http.Header{"Www-Authenticate": {`Digest realm="Wowza", qop="auth", nonce="loop"`}},
+	})
+	settings := srv.Settings()
+	settings.SetUseDigest(true)
+	settings.SetTransport(faults.Transport(nil))
+	if _, err := wserest.NewApplication(settings, "live", "", "", "", "").Get(); !wserest.IsUnauthorized(err) {
+		t.Fatalf("expected the 401 loop to end with unauthorized, got %v", err)
+	}
+	if faults.Injected() > 3 {
+		t.Fatalf("expected challenges to be bounded, got %d requests", faults.Injected())
+	}
+
+	faults = wsetest.NewFaultInjector(wsetest.Fault{Probability: 0.5, Disconnect: true})
+	faults.SetSeed(1)
+	settings = srv.Settings()
+	settings.SetRetryPolicy(nil)
+	settings.SetTransport(faults.Transport(nil))
+	failures := 0
+	for i := 0; i < 100; i++ {
+		if _, err := wserest.NewApplication(settings, "live", "", "", "", "").Get(); err != nil {
+			failures++
+		}
+	}
+	if failures != faults.Injected() || failures < 30 || failures > 70 {
+		t.Fatalf("expected about half of the requests to fail, got %d failures for %d faults", failures, faults.Injected())
+	}
+}
+
+func TestFaultInjectorFallthrough(t *testing.T) {
+	srv := wsetest.NewServer()
+	defer srv.Close()
+
+	faults := wsetest.NewFaultInjector(
+		wsetest.Fault{Probability: 0.5, Status: http.StatusServiceUnavailable},
+		wsetest.Fault{Status: http.StatusBadGateway},
+	)
+	faults.SetSeed(1)
+	client := &http.Client{Transport: faults.Transport(nil)}
+	statuses := map[string]int{}
+	for i := 0; i < 50; i++ {
+		resp, err := client.Get(srv.URL + "/v2/servers/" + wsetest.ServerName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		statuses[resp.Status]++
+	}
+	if len(statuses) != 2 || statuses["503 Service Unavailable"] == 0 || statuses["502 Bad Gateway"] == 0 {
+		t.Fatalf("expected the requests spared by the first fault to get the second one, got %v", statuses)
+	}
+}
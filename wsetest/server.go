@@ -0,0 +1,501 @@
+// Package wsetest provides an in-process fake of the Wowza Streaming Engine
+// v2 REST API, for tests that cannot reach a licensed server.
+//
+//	srv := wsetest.NewServer(wsetest.WithDigestAuth("admin", "secret"))
+//	defer srv.Close()
+//	app := wserest.NewApplication(srv.Settings(), "live", "Live", "", "", "")
+//
+// The state is kept in memory: applications with their advanced settings,
+// stream files, SMIL files, push publish map entries, stream recorders, DVR
+// stores, users, publishers and log files. Monitoring endpoints answer with
+// static statistics.
+package wsetest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sebastien4/wse-rest-library-go/entity/application/helper"
+	"github.com/sebastien4/wse-rest-library-go/httpauth"
+)
+
+// ServerName is the name reported by the fake server
+const ServerName = "_defaultServer_"
+
+// Version is the Wowza Streaming Engine version announced by default
+const Version = "4.8.5"
+
+// nameFields lists the body fields naming an item posted to a collection
+var nameFields = []string{"name", "userName", "recorderName", "entryName", "dvrStoreName"}
+
+// secretFields lists the fields left out of the responses
+var secretFields = map[string]bool{"password": true}
+
+// listKeys maps collections to the key of their items in a GET response
+var listKeys = map[string]string{
+	"applications":    "applications",
+	"streamfiles":     "streamFiles",
+	"smilfiles":       "smilFiles",
+	"mapentries":      "mapEntries",
+	"streamrecorders": "streamrecorder",
+	"users":           "users",
+	"publishers":      "publishers",
+	"dvrstores":       "dvrconverterstoresummary",
+	"logfiles":        "logFiles",
+}
+
+// Request is a request received by the server, its Path is escaped so that
+// the names holding a "/" stay a single segment
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   map[string]interface{}
+}
+
+// Server is a fake Wowza Streaming Engine REST API
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	username string
+	password string
+	nonce    string
+	version  string
+	items    map[string]map[string]interface{}
+	logLines []string
+	requests []Request
+	faults   *FaultInjector
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithDigestAuth requires digest authentication with the given credentials
+func WithDigestAuth(username, password string) Option {
+	return func(s *Server) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// WithVersion announces version in the Server header of the responses,
+// an empty version leaves the header out
+func WithVersion(version string) Option {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+// NewServer starts a fake server, it must be closed by the caller
+func NewServer(options ...Option) *Server {
+	s := &Server{
+		nonce:   "wsetest-nonce",
+		version: Version,
+		items:   make(map[string]map[string]interface{}),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	var handler http.Handler = http.HandlerFunc(s.serveHTTP)
+	if s.faults != nil {
+		handler = s.faults.Handler(handler)
+	}
+	s.Server = httptest.NewServer(handler)
+	return s
+}
+
+// Settings returns settings pointing at the server, with its credentials
+func (s *Server) Settings() *helper.Settings {
+	settings := helper.NewDefaultSettings()
+	settings.SetHost(s.URL + "/v2")
+	settings.SetServerInstance(ServerName)
+	if s.username != "" {
+		settings.SetUseDigest(true)
+		settings.SetUsername(s.username)
+		settings.SetPassword(s.password)
+	}
+	return settings
+}
+
+// Requests returns the requests received so far, challenged ones excluded
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Item returns the stored properties of the item at path, escaped and
+// relative to /v2, such as "/servers/_defaultServer_/users/bob"
+func (s *Server) Item(path string) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	item, ok := s.items[path]
+	return item, ok
+}
+
+// SetItem stores an item at path, escaped and relative to /v2, for instance
+// to seed the server before a test
+func (s *Server) SetItem(path string, props map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items[path] = props
+}
+
+// AddApplication stores an application of the default virtual host
+func (s *Server) AddApplication(name, appType string) {
+	s.SetItem(vhostPath()+"/applications/"+url.PathEscape(name), map[string]interface{}{"name": name, "appType": appType})
+}
+
+// AddDVRStore stores a DVR store of an application instance
+func (s *Server) AddDVRStore(appName, appInstance, storeName string) {
+	s.SetItem(vhostPath()+"/applications/"+url.PathEscape(appName)+"/instances/"+url.PathEscape(appInstance)+"/dvrstores/"+url.PathEscape(storeName), map[string]interface{}{
+		"name":     storeName,
+		"location": "/usr/local/WowzaStreamingEngine/content/" + storeName,
+	})
+}
+
+// AddLogLines appends lines to the access log
+func (s *Server) AddLogLines(lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logLines = append(s.logLines, lines...)
+}
+
+func vhostPath() string {
+	return "/servers/" + ServerName + "/vhosts/_defaultVHost_"
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if s.version != "" {
+		w.Header().Set("Server", "WowzaStreamingEngine/"+s.version)
+	}
+	if s.username != "" && !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest realm="Wowza", qop="auth", nonce="%s", opaque="wsetest"`, s.nonce))
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"success": false, "message": "Unauthorized"})
+		return
+	}
+
+	props := make(map[string]interface{})
+	json.Unmarshal(body, &props)
+	delete(props, "restURI")
+
+	path := strings.TrimPrefix(r.URL.EscapedPath(), "/v2")
+	path = strings.TrimSuffix(path, "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: path, Query: r.URL.RawQuery, Body: props})
+
+	status, response := s.route(r.Method, path, r.URL.Query(), props)
+	writeJSON(w, status, response)
+}
+
+// authorized checks the digest credentials of r, qop auth and MD5 only
+func (s *Server) authorized(r *http.Request) bool {
+	credentials, err := httpauth.ParseChallenges(r.Header.Get("Authorization"))
+	if err != nil || len(credentials) != 1 || !strings.EqualFold(credentials[0].Scheme, "Digest") {
+		return false
+	}
+	p := credentials[0].Params
+	if p["username"] != s.username || p["nonce"] != s.nonce {
+		return false
+	}
+	ha1 := md5Hex(s.username + ":Wowza:" + s.password)
+	ha2 := md5Hex(r.Method + ":" + p["uri"])
+	if p["qop"] == "" {
+		return p["response"] == md5Hex(ha1+":"+p["nonce"]+":"+ha2)
+	}
+	return p["response"] == md5Hex(ha1+":"+p["nonce"]+":"+p["nc"]+":"+p["cnonce"]+":"+p["qop"]+":"+ha2)
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func success(message string) map[string]interface{} {
+	return map[string]interface{}{"success": true, "message": message}
+}
+
+func failure(message string) map[string]interface{} {
+	return map[string]interface{}{"success": false, "message": message}
+}
+
+// route answers a request, s.mu is held
+func (s *Server) route(method, path string, query map[string][]string, props map[string]interface{}) (int, interface{}) {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	last := segments[len(segments)-1]
+
+	switch {
+	case strings.HasSuffix(path, "/monitoring/current"), strings.HasSuffix(path, "/monitoring/historic"):
+		return s.monitoring(path)
+	case len(segments) >= 2 && segments[len(segments)-2] == "actions":
+		return s.action(method, strings.TrimSuffix(path, "/actions/"+last), last)
+	case strings.Contains(path, "/logfiles"):
+		return s.logFiles(method, segments, query)
+	case last == "adv":
+		return s.advanced(method, strings.TrimSuffix(path, "/adv"), props)
+	case last == "default" && strings.Contains(path, "/streamrecorders/"):
+		return http.StatusOK, map[string]interface{}{"recorderName": "default", "defaultRecorder": true, "segmentationType": "None"}
+	}
+
+	if s.missingApplication(path) {
+		return http.StatusNotFound, failure("Application not found.")
+	}
+
+	switch method {
+	case http.MethodGet:
+		if item, ok := s.items[path]; ok {
+			return http.StatusOK, s.view(path, item)
+		}
+		if key, ok := listKeys[last]; ok {
+			return http.StatusOK, s.list(path, key)
+		}
+		return http.StatusNotFound, failure(fmt.Sprintf("%s not found.", last))
+	case http.MethodPost:
+		if _, ok := listKeys[last]; ok {
+			name := nameOf(props)
+			if name == "" {
+				return http.StatusOK, success("Created")
+			}
+			path += "/" + url.PathEscape(name)
+		}
+		if _, ok := s.items[path]; ok {
+			return http.StatusConflict, failure(fmt.Sprintf("%s already exists.", path))
+		}
+		s.items[path] = props
+		return http.StatusCreated, success(fmt.Sprintf("%s created.", path))
+	case http.MethodPut:
+		item, ok := s.items[path]
+		if !ok {
+			return http.StatusNotFound, failure(fmt.Sprintf("%s not found.", last))
+		}
+		for k, v := range props {
+			item[k] = v
+		}
+		return http.StatusOK, success(fmt.Sprintf("%s updated.", path))
+	case http.MethodDelete:
+		if _, ok := s.items[path]; !ok {
+			return http.StatusNotFound, failure(fmt.Sprintf("%s not found.", last))
+		}
+		for p := range s.items {
+			if p == path || strings.HasPrefix(p, path+"/") {
+				delete(s.items, p)
+			}
+		}
+		return http.StatusOK, success(fmt.Sprintf("%s deleted.", path))
+	}
+	return http.StatusMethodNotAllowed, failure("Method not allowed.")
+}
+
+// application returns the path of the application path belongs to, if any
+func application(path string) string {
+	i := strings.Index(path, "/applications/")
+	if i < 0 {
+		return ""
+	}
+	rest := path[i+len("/applications/"):]
+	if j := strings.Index(rest, "/"); j >= 0 {
+		return path[:i+len("/applications/")+j]
+	}
+	return ""
+}
+
+func (s *Server) missingApplication(path string) bool {
+	app := application(path)
+	if app == "" {
+		return false
+	}
+	_, ok := s.items[app]
+	return !ok
+}
+
+// unescape returns the name held by an escaped path segment
+func unescape(segment string) string {
+	if name, err := url.PathUnescape(segment); err == nil {
+		return name
+	}
+	return segment
+}
+
+func nameOf(props map[string]interface{}) string {
+	for _, field := range nameFields {
+		if name, ok := props[field].(string); ok && name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// view returns the representation of an item, without its secrets
+func (s *Server) view(path string, item map[string]interface{}) map[string]interface{} {
+	v := map[string]interface{}{"serverName": ServerName}
+	for k, value := range item {
+		if !secretFields[k] {
+			v[k] = value
+		}
+	}
+	if strings.Contains(path, "/dvrstores/") {
+		name := unescape(path[strings.LastIndex(path, "/")+1:])
+		state, _ := item["conversionState"].(string)
+		if state == "" {
+			state = "STOPPED"
+		}
+		v["dvrStoreName"] = name
+		v["DvrConverterStore"] = map[string]interface{}{
+			"dvrStoreName":     name,
+			"audioAvailable":   true,
+			"videoAvailable":   true,
+			"conversionStatus": map[string]interface{}{"storeName": name, "state": state},
+		}
+	}
+	return v
+}
+
+// list returns the items directly under path
+func (s *Server) list(path, key string) map[string]interface{} {
+	var names []string
+	for p := range s.items {
+		if strings.HasPrefix(p, path+"/") && !strings.Contains(p[len(path)+1:], "/") {
+			names = append(names, p[len(path)+1:])
+		}
+	}
+	sort.Strings(names)
+
+	entries := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		entry := s.view(path+"/"+name, s.items[path+"/"+name])
+		delete(entry, "serverName")
+		entry["id"] = unescape(name)
+		entry["href"] = "/v2" + path + "/" + name
+		entries = append(entries, entry)
+	}
+	list := map[string]interface{}{"serverName": ServerName, key: entries}
+	if s.version != "" {
+		list["version"] = s.version
+	}
+	return list
+}
+
+func (s *Server) advanced(method, path string, props map[string]interface{}) (int, interface{}) {
+	item, ok := s.items[path]
+	if !ok {
+		return http.StatusNotFound, failure(fmt.Sprintf("%s not found.", path))
+	}
+	switch method {
+	case http.MethodGet:
+		adv, _ := item["adv"].(map[string]interface{})
+		if adv == nil {
+			adv = map[string]interface{}{"advancedSettings": []interface{}{}, "modules": map[string]interface{}{"moduleList": []interface{}{}}}
+		}
+		return http.StatusOK, adv
+	case http.MethodPut:
+		item["adv"] = props
+		return http.StatusOK, success("Advanced settings updated.")
+	}
+	return http.StatusMethodNotAllowed, failure("Method not allowed.")
+}
+
+func (s *Server) action(method, path, action string) (int, interface{}) {
+	if method != http.MethodPut {
+		return http.StatusMethodNotAllowed, failure("Method not allowed.")
+	}
+	if s.missingApplication(path) {
+		return http.StatusNotFound, failure("Application not found.")
+	}
+	switch {
+	case strings.HasSuffix(path, "/dvrstores"):
+		// convert and expire apply to the whole collection
+	case strings.Contains(path, "/incomingstreams/"):
+		// incoming streams are not tracked
+	default:
+		item, ok := s.items[path]
+		if !ok {
+			return http.StatusNotFound, failure(fmt.Sprintf("%s not found.", path))
+		}
+		switch action {
+		case "convert":
+			item["conversionState"] = "SUCCESSFUL"
+		case "stopRecording":
+			item["recorderState"] = "Stopped"
+		case "connect":
+			item["connected"] = true
+		}
+	}
+	return http.StatusOK, success(fmt.Sprintf("%s done.", action))
+}
+
+func (s *Server) monitoring(path string) (int, interface{}) {
+	if s.missingApplication(path) {
+		return http.StatusNotFound, failure("Application not found.")
+	}
+	return http.StatusOK, map[string]interface{}{
+		"serverName":          ServerName,
+		"uptime":              3600,
+		"bytesIn":             0,
+		"bytesOut":            0,
+		"totalConnections":    0,
+		"connectionCount":     map[string]interface{}{"RTMP": 0, "CUPERTINO": 0, "SANJOSE": 0},
+		"cpuUser":             1,
+		"cpuSystem":           1,
+		"cpuIdle":             98,
+		"memoryFree":          1 << 30,
+		"heapUsed":            1 << 27,
+		"serverUptime":        3600,
+		"availableProcessors": 4,
+	}
+}
+
+func (s *Server) logFiles(method string, segments []string, query map[string][]string) (int, interface{}) {
+	if method != http.MethodGet {
+		return http.StatusMethodNotAllowed, failure("Method not allowed.")
+	}
+	if segments[len(segments)-1] == "logfiles" {
+		return http.StatusOK, map[string]interface{}{
+			"serverName": ServerName,
+			"logFiles":   []map[string]interface{}{{"id": "wowzastreamingengine_access.log"}},
+		}
+	}
+
+	lines := s.logLines
+	if search := first(query["search"]); search != "" {
+		lines = nil
+		for _, line := range s.logLines {
+			if strings.Contains(line, search) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	if n, err := strconv.Atoi(first(query["lineCount"])); err == nil && n >= 0 && n < len(lines) {
+		lines = lines[len(lines)-n:]
+	}
+	if lines == nil {
+		lines = []string{}
+	}
+	return http.StatusOK, map[string]interface{}{"serverName": ServerName, "logLines": lines}
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
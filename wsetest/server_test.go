@@ -0,0 +1,198 @@
+package wsetest_test
+
+import (
+	"errors"
+	"testing"
+
+	wserest "github.com/sebastien4/wse-rest-library-go"
+	"github.com/sebastien4/wse-rest-library-go/entity/application"
+	"github.com/sebastien4/wse-rest-library-go/wsetest"
+)
+
+func TestServer(t *testing.T) {
+	srv := wsetest.NewServer(wsetest.WithDigestAuth("admin", "secret"))
+	defer srv.Close()
+	settings := srv.Settings()
+
+	must := func(response map[string]interface{}, err error) map[string]interface{} {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	app := wserest.NewApplication(settings, "live", "Live", "", "", "")
+	if _, err := app.Get(); !wserest.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	must(app.Create(application.NewStreamConfig(), nil, nil, nil, nil, nil))
+	if _, err := app.Create(nil, nil, nil, nil, nil, nil); !wserest.IsConflict(err) {
+		t.Fatalf("expected conflict, got %v", err)
+	}
+	if got := must(app.Get()); got["appType"] != "Live" {
+		t.Errorf("unexpected application %v", got)
+	}
+	must(app.UpdateAdvanced(&application.AdvancedSettings{}, application.NewModules()))
+	if got := must(app.GetAdvanced()); got["modules"] == nil {
+		t.Errorf("unexpected advanced settings %v", got)
+	}
+	apps, err := app.GetAll()
+	if err != nil || len(apps.Applications) != 1 || apps.Applications[0].ID != "live" {
+		t.Fatalf("unexpected applications %+v, %v", apps, err)
+	}
+
+	streamFile := wserest.NewStreamFile(settings, "live", "camera")
+	must(streamFile.Create(map[string]interface{}{"uri": "rtsp://camera"}, "", ""))
+	must(streamFile.Connect(""))
+	must(streamFile.Disconnect())
+	if got := must(streamFile.GetAll()); len(got["streamFiles"].([]interface{})) != 1 {
+		t.Errorf("unexpected stream files %v", got)
+	}
+	must(streamFile.Remove())
+	if _, err = streamFile.Get(); !wserest.IsNotFound(err) {
+		t.Fatalf("expected the stream file to be removed, got %v", err)
+	}
+
+	smil := wserest.NewSmilFile(settings, "live")
+	must(smil.Create("abr", []map[string]interface{}{{"src": "mp4:low.mp4"}}))
+	must(smil.Get("abr"))
+	must(smil.Remove("abr"))
+
+	target := wserest.NewStreamTarget(settings, "live")
+	must(target.Create("camera", "cdn", "rtmp", "cdn.example.com", "user", "pass", "camera", "ingest"))
+	if got := must(target.GetAll()); len(got["mapEntries"].([]interface{})) != 1 {
+		t.Errorf("unexpected map entries %v", got)
+	}
+	must(target.Remove("cdn"))
+
+	recording := wserest.NewRecording(settings, "live", "")
+	must(recording.Create("camera", "_definst_", "Waiting for stream", false, "None", "", "", "2", "", "", 0, 0, "", false, true, false, "Version", false, 0, 0, ""))
+	must(recording.GetRecorder("camera"))
+	must(recording.GetDefaultParams("camera"))
+	must(recording.Stop("camera"))
+	if _, err = recording.Split("missing"); !wserest.IsNotFound(err) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	srv.AddDVRStore("live", "_definst_", "camera.0")
+	dvr := wserest.NewDvrClipExtraction(settings, "live", "")
+	stores, err := dvr.GetAll()
+	if err != nil || len(stores.DVRConverterStoreSummary) != 1 || stores.DVRConverterStoreSummary[0].ID != "camera.0" {
+		t.Fatalf("unexpected DVR stores %+v, %v", stores, err)
+	}
+	must(dvr.Convert("camera.0", 0, 0, "", "clip.mp4", false))
+	converter, err := dvr.GetItem("camera.0")
+	if err != nil || converter.DVRConverterStore.DVRConversionStatus.State != "SUCCESSFUL" {
+		t.Fatalf("unexpected converter %+v, %v", converter, err)
+	}
+	must(dvr.Remove("camera.0"))
+
+	stats := wserest.NewStatistics(settings)
+	must(stats.GetApplicationStatistics(app))
+	must(stats.GetIncomingApplicationStatistics(app, "camera", ""))
+	must(stats.GetServerStatistics(wserest.NewServer(settings)))
+
+	user := wserest.NewUser(settings, "bob")
+	must(user.Create("bob-secret", []string{"admin"}))
+	users := must(user.GetAll())
+	if entries := users["users"].([]interface{}); len(entries) != 1 || entries[0].(map[string]interface{})["password"] != nil {
+		t.Errorf("expected one user without its password, got %v", users)
+	}
+	must(user.Remove())
+
+	publisher := wserest.NewPublisher(settings, "encoder")
+	must(publisher.Create("encoder-secret"))
+	must(publisher.GetAll())
+	must(publisher.Remove())
+
+	srv.AddLogLines("first line", "second line", "third line")
+	logging := wserest.NewLogging(settings)
+	if got := must(logging.GetLineCount(2)); len(got["logLines"].([]interface{})) != 2 {
+		t.Errorf("unexpected log lines %v", got)
+	}
+	if got := must(logging.Search("second")); len(got["logLines"].([]interface{})) != 1 {
+		t.Errorf("unexpected search result %v", got)
+	}
+
+	must(app.Remove())
+	if _, err = wserest.NewStreamFile(settings, "live", "other").Create(nil, "", ""); !wserest.IsNotFound(err) {
+		t.Fatalf("expected stream files of a removed application to be rejected, got %v", err)
+	}
+}
+
+func TestServerDigestAuth(t *testing.T) {
+	srv := wsetest.NewServer(wsetest.WithDigestAuth("admin", "secret"))
+	defer srv.Close()
+
+	settings := srv.Settings()
+	settings.SetPassword("wrong")
+	if _, err := wserest.NewApplication(settings, "live", "", "", "", "").Get(); !wserest.IsUnauthorized(err) {
+		t.Fatalf("expected unauthorized, got %v", err)
+	}
+	if len(srv.Requests()) != 0 {
+		t.Fatalf("expected unauthenticated requests not to be recorded, got %v", srv.Requests())
+	}
+}
+
+func TestServerDigestAuthQuoted(t *testing.T) {
+	srv := wsetest.NewServer(wsetest.WithDigestAuth(`ad"m,in`, "secret"))
+	defer srv.Close()
+
+	if _, err := wserest.NewApplication(srv.Settings(), "live", "", "", "", "").GetAll(); err != nil {
+		t.Fatalf("expected a quoted username to authenticate, got %v", err)
+	}
+}
+
+func TestServerVersion(t *testing.T) {
+	srv := wsetest.NewServer(wsetest.WithVersion("4.0.5"))
+	defer srv.Close()
+	settings := srv.Settings()
+	v, err := wserest.NewServer(settings).Version()
+	if err != nil || v.String() != "4.0.5" {
+		t.Fatalf("got version %v, %v", v, err)
+	}
+
+	users, err := wserest.NewUser(settings, "").GetAll()
+	if err != nil || users["version"] != "4.0.5" {
+		t.Fatalf("expected lists to report version 4.0.5, got %v, %v", users["version"], err)
+	}
+
+	if err = wserest.NewServer(settings).RequireVersion(wserest.ServerVersion{Major: 4, Minor: 7}); !errors.Is(err, wserest.ErrUnsupportedByServer) {
+		t.Fatalf("expected ErrUnsupportedByServer, got %v", err)
+	}
+}
+
+func TestServerEscapedNames(t *testing.T) {
+	srv := wsetest.NewServer()
+	defer srv.Close()
+	settings := srv.Settings()
+
+	for _, name := range []string{"ops/admin", "what?"} {
+		if _, err := wserest.NewUser(settings, name).Create("secret", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	users, err := wserest.NewServer(settings).GetUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, _ := users["users"].([]interface{})
+	var names []string
+	for _, user := range list {
+		names = append(names, user.(map[string]interface{})["id"].(string))
+	}
+	if len(names) != 2 || names[0] != "ops/admin" || names[1] != "what?" {
+		t.Fatalf("expected the users to be listed under their names, got %v", names)
+	}
+	if _, ok := srv.Item("/servers/" + wsetest.ServerName + "/users/ops%2Fadmin"); !ok {
+		t.Fatal("expected the user to be stored under its escaped name")
+	}
+
+	if _, err = wserest.NewUser(settings, "ops/admin").Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if users, err = wserest.NewServer(settings).GetUsers(); err != nil || len(users["users"].([]interface{})) != 1 {
+		t.Fatalf("expected the user to be removed, got %v, %v", users, err)
+	}
+}
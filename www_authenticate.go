@@ -1,11 +1,14 @@
 package wserest
 
 import (
-	"regexp"
+	"net/http"
 	"strings"
+
+	"github.com/sebastien4/wse-rest-library-go/httpauth"
 )
 
 type wwwAuthenticate struct {
+	Scheme    string
 	Algorithm string // unquoted
 	Domain    string // quoted
 	Nonce     string // quoted
@@ -21,59 +24,71 @@ func newWwwAuthenticate(s string) *wwwAuthenticate {
 
 	var wa = wwwAuthenticate{}
 
-	algorithmRegex := regexp.MustCompile(`algorithm=([^ ,]+)`)
-	algorithmMatch := algorithmRegex.FindStringSubmatch(s)
-	if algorithmMatch != nil {
-		wa.Algorithm = algorithmMatch[1]
+	challenges, _ := httpauth.ParseChallenges(s)
+	if len(challenges) == 0 {
+		return &wa
 	}
 
-	domainRegex := regexp.MustCompile(`domain="(.+?)"`)
-	domainMatch := domainRegex.FindStringSubmatch(s)
-	if domainMatch != nil {
-		wa.Domain = domainMatch[1]
-	}
+	return digestParams(challenges[0])
+}
 
-	nonceRegex := regexp.MustCompile(`nonce="(.+?)"`)
-	nonceMatch := nonceRegex.FindStringSubmatch(s)
-	if nonceMatch != nil {
-		wa.Nonce = nonceMatch[1]
-	}
+// digestParams extracts the Digest parameters of c
+func digestParams(c httpauth.Challenge) *wwwAuthenticate {
 
-	opaqueRegex := regexp.MustCompile(`opaque="(.+?)"`)
-	opaqueMatch := opaqueRegex.FindStringSubmatch(s)
-	if opaqueMatch != nil {
-		wa.Opaque = opaqueMatch[1]
-	}
+	var wa = wwwAuthenticate{}
 
-	qopRegex := regexp.MustCompile(`qop="(.+?)"`)
-	qopMatch := qopRegex.FindStringSubmatch(s)
-	if qopMatch != nil {
-		wa.Qop = qopMatch[1]
-	}
+	wa.Scheme = c.Scheme
+	wa.Algorithm = c.Params["algorithm"]
+	wa.Domain = c.Params["domain"]
+	wa.Nonce = c.Params["nonce"]
+	wa.Opaque = c.Params["opaque"]
+	wa.Qop = c.Params["qop"]
+	wa.Realm = c.Params["realm"]
+	wa.Stale = strings.EqualFold(c.Params["stale"], "true")
+	wa.Charset = c.Params["charset"]
+	wa.Userhash = strings.EqualFold(c.Params["userhash"], "true")
 
-	realmRegex := regexp.MustCompile(`realm="(.+?)"`)
-	realmMatch := realmRegex.FindStringSubmatch(s)
-	if realmMatch != nil {
-		wa.Realm = realmMatch[1]
-	}
+	return &wa
+}
 
-	staleRegex := regexp.MustCompile(`stale=([^ ,])"`)
-	staleMatch := staleRegex.FindStringSubmatch(s)
-	if staleMatch != nil {
-		wa.Stale = (strings.ToLower(staleMatch[1]) == "true")
+// qops returns the quality of protection values offered by the challenge
+func (wa *wwwAuthenticate) qops() []string {
+	var qops []string
+	for _, qop := range strings.Split(wa.Qop, ",") {
+		if qop = strings.TrimSpace(qop); qop != "" {
+			qops = append(qops, strings.ToLower(qop))
+		}
 	}
+	return qops
+}
 
-	charsetRegex := regexp.MustCompile(`charset="(.+?)"`)
-	charsetMatch := charsetRegex.FindStringSubmatch(s)
-	if charsetMatch != nil {
-		wa.Charset = charsetMatch[1]
+// parseDigestChallenges returns the Digest challenges found in the
+// WWW-Authenticate headers of h, in the order the server sent them
+func parseDigestChallenges(h http.Header) []*wwwAuthenticate {
+	var challenges []*wwwAuthenticate
+	for _, v := range h.Values("WWW-Authenticate") {
+		// keep the challenges parsed before a syntax error
+		parsed, _ := httpauth.ParseChallenges(v)
+		for _, c := range parsed {
+			if strings.EqualFold(c.Scheme, "Digest") {
+				challenges = append(challenges, digestParams(c))
+			}
+		}
 	}
+	return challenges
+}
 
-	userhashRegex := regexp.MustCompile(`userhash=([^ ,])"`)
-	userhashMatch := userhashRegex.FindStringSubmatch(s)
-	if userhashMatch != nil {
-		wa.Userhash = (strings.ToLower(userhashMatch[1]) == "true")
+// bestDigestChallenge picks the challenge with the strongest supported
+// algorithm, or nil when none is supported
+func bestDigestChallenge(challenges []*wwwAuthenticate) *wwwAuthenticate {
+	var (
+		best     *wwwAuthenticate
+		bestRank int
+	)
+	for _, wa := range challenges {
+		if rank := algorithmRank(wa.Algorithm); rank > bestRank {
+			best, bestRank = wa, rank
+		}
 	}
-
-	return &wa
+	return best
 }
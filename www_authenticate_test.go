@@ -0,0 +1,149 @@
+package wserest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sebastien4/wse-rest-library-go/httpauth"
+)
+
+// rfc7616Request is the request of the RFC 7616 section 3.9.1 example
+func rfc7616Request(algorithm string) (*authorization, *digestRequest) {
+	dr := &digestRequest{Method: "GET", Username: "Mufasa", Password: "Circle of Life"}
+	ah := &authorization{
+		Algorithm: algorithm,
+		Cnonce:    "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+		Nc:        1,
+		Nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+		Opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+		Qop:       "auth",
+		Realm:     "http-auth@example.org",
+		URI:       "/dir/index.html",
+		Username:  "Mufasa",
+	}
+	return ah, dr
+}
+
+func TestDigestKnownAnswers(t *testing.T) {
+	tests := []struct {
+		name string
+		ah   *authorization
+		dr   *digestRequest
+		want string
+	}{
+		{
+			// RFC 2617 section 3.5
+			name: "RFC 2617 MD5",
+			ah: &authorization{Cnonce: "0a4f113b", Nc: 1, Nonce: "dcd98b7102dd2f0e8b11d0f600bfb0c093", Qop: "auth",
+				Realm: "testrealm@host.com", URI: "/dir/index.html"},
+			dr:   &digestRequest{Method: "GET", Username: "Mufasa", Password: "Circle Of Life"},
+			want: "6629fae49393a05397450978507c4ef1",
+		},
+		{
+			// RFC 7616 section 3.9.1
+			name: "RFC 7616 MD5",
+			want: "8ca523f5e9506fed4657c9700eebdbec",
+		},
+		{
+			// RFC 7616 section 3.9.1
+			name: "RFC 7616 SHA-256",
+			want: "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1",
+		},
+	}
+	tests[1].ah, tests[1].dr = rfc7616Request("MD5")
+	tests[2].ah, tests[2].dr = rfc7616Request("SHA-256")
+
+	for _, tt := range tests {
+		if got := tt.ah.computeResponse(tt.dr); got != tt.want {
+			t.Errorf("%s: got response %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDigestAuthIntKnownAnswers(t *testing.T) {
+	for algorithm, want := range map[string]string{
+		"MD5":     "b462f5fe3f12da4087c3d72ad54c69ac",
+		"SHA-256": "b3b4b67b1816166c2dd86ffc5faac5a019823d5096019674b0e78a2b026c892b",
+	} {
+		ah, dr := rfc7616Request(algorithm)
+		ah.Qop = "auth-int"
+		dr.Method = "POST"
+		dr.Body = `{"name":"live"}`
+		if got := ah.computeResponse(dr); got != want {
+			t.Errorf("%s: got response %s, want %s", algorithm, got, want)
+		}
+	}
+}
+
+func TestDigestSessionKnownAnswers(t *testing.T) {
+	for algorithm, want := range map[string][]string{
+		"MD5-sess":     {"e783283f46242139c486a698fec7211d", "6914b51e16f9459d9abc967ad41c4599"},
+		"SHA-256-sess": {"2fd51b3a77ad75bad6afad6003e818d767133c46d9e2749e7f5232ae1ea3efd7", "6bb0010aa4bdf46422a798c509ea32e256f27bd37de5cc3bdf8ed51e1d77d650"},
+	} {
+		ah, dr := rfc7616Request(algorithm)
+		ah.Nc = 0
+		dr.URI = "http://www.example.org/dir/index.html"
+		cnonce := ah.Cnonce
+		for i, response := range want {
+			if _, err := ah.refreshAuthorization(dr); err != nil {
+				t.Fatal(err)
+			}
+			if ah.Response != response || ah.Cnonce != cnonce {
+				t.Errorf("%s request %d: got response %s with cnonce %s, want %s with %s", algorithm, i+1, ah.Response, ah.Cnonce, response, cnonce)
+			}
+		}
+	}
+}
+
+func TestDigestUserhash(t *testing.T) {
+	ah, dr := rfc7616Request("SHA-256")
+	ah.Userhash = true
+	dr.URI = "http://www.example.org/dir/index.html"
+	if _, err := ah.refreshAuthorization(dr); err != nil {
+		t.Fatal(err)
+	}
+	// SHA-256("Mufasa:http-auth@example.org")
+	if want := "a947aad205e80e429958a387394944c6b496301e79f89d35a4cc23b6ee12b5b6"; ah.Username != want {
+		t.Fatalf("got username %s, want %s", ah.Username, want)
+	}
+	if !strings.Contains(ah.toString(), "userhash=true") {
+		t.Fatalf("missing userhash in %s", ah.toString())
+	}
+}
+
+func TestAuthorizationQuoting(t *testing.T) {
+	ah, _ := rfc7616Request("SHA-256")
+	ah.Realm = `Wowza "Streaming", Engine \ 4`
+	ah.Username = `admin", response="forged`
+
+	got := newWwwAuthenticate(ah.toString())
+	if got.Realm != ah.Realm || got.Nonce != ah.Nonce {
+		t.Fatalf("got %+v", got)
+	}
+	c, err := httpauth.ParseChallenges(ah.toString())
+	if err != nil || len(c) != 1 {
+		t.Fatalf("got %+v, %v", c, err)
+	}
+	if c[0].Params["username"] != ah.Username || c[0].Params["uri"] != ah.URI {
+		t.Fatalf("got params %q", c[0].Params)
+	}
+}
+
+func FuzzAuthorizationToString(f *testing.F) {
+	f.Add("Wowza", "admin", "/v2/servers")
+	f.Add(`Wowza "Streaming", Engine`, `a\"b`, "/v2/a,b")
+	f.Add("", "", "")
+
+	f.Fuzz(func(t *testing.T, realm, username, uri string) {
+		ah, _ := rfc7616Request("MD5")
+		ah.Realm, ah.Username, ah.URI = realm, username, uri
+
+		c, err := httpauth.ParseChallenges(ah.toString())
+		if err != nil || len(c) != 1 {
+			t.Fatalf("failed to parse %q: %+v, %v", ah.toString(), c, err)
+		}
+		if c[0].Params["realm"] != realm || c[0].Params["username"] != username || c[0].Params["uri"] != uri {
+			t.Fatalf("got params %q", c[0].Params)
+		}
+	})
+}